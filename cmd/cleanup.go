@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/coding-ia/renovate-controller/internal/processor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"log"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove generated files",
+	Long:  `Scrub and remove the generated config and token files, revoking the token file before it is removed`,
+	Run:   cleanupCommand,
+}
+
+func cleanupCommand(cmd *cobra.Command, args []string) {
+	options := processor.CleanupOptions{
+		Paths:      splitList(viper.GetStringSlice("path")),
+		Delay:      viper.GetDuration("delay"),
+		TokenFile:  viper.GetString("token-file"),
+		Endpoint:   viper.GetString("endpoint"),
+		HTTPClient: newHTTPClient(),
+	}
+
+	err := processor.Cleanup(options)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"github.com/coding-ia/renovate-controller/internal/awsclient"
+	"github.com/coding-ia/renovate-controller/internal/httpclient"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
+	"github.com/spf13/viper"
+	"log"
+	"net/http"
+)
+
+func parseHTTPConfig() httpclient.Config {
+	return httpclient.Config{
+		CABundle:   viper.GetString("ca-bundle"),
+		Proxy:      viper.GetString("proxy"),
+		ClientCert: viper.GetString("client-cert"),
+		ClientKey:  viper.GetString("client-key"),
+	}
+}
+
+// newHTTPClient builds the client the GitHub, AWS, Vault and template
+// clients send their requests with, applying the CA bundle, proxy and client
+// certificate.
+func newHTTPClient() *http.Client {
+	httpClient, err := parseHTTPConfig().Client()
+	if err != nil {
+		log.Fatalf("Error configuring HTTP client: %v", err)
+	}
+	return httpClient
+}
+
+// initAWSClients builds the AWS clients of a command once, so private keys,
+// templates and host rules all share one config.
+func initAWSClients(httpClient *http.Client) *awsclient.Clients {
+	clients, err := awsclient.Load(context.Background(), httpClient)
+	if err != nil {
+		log.Fatalf("Error loading AWS config: %v", err)
+	}
+	return clients
+}
+
+// newSecretProviders returns the secret providers of a command, backed by
+// its AWS clients and HTTP client.
+func newSecretProviders(clients *awsclient.Clients, httpClient *http.Client) secrets.Providers {
+	return secrets.NewProviders(clients.SecretsManager, clients.SSM, httpClient, clients.Config.Credentials)
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
-	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/hostrules"
 	"github.com/coding-ia/renovate-controller/internal/processor"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 )
 
 var generateConfigCmd = &cobra.Command{
@@ -16,33 +21,87 @@ var generateConfigCmd = &cobra.Command{
 }
 
 func generateConfigCommand(cmd *cobra.Command, args []string) {
-	appId := viper.GetString("appId")
-	pemSecretArn := viper.GetString("pem-aws-secret")
-	githubEndpoint := viper.GetString("endpoint")
 	installationId := viper.GetInt64("installationId")
 	targetRepository := viper.GetString("target-repository")
+	mode := viper.GetString("mode")
+	templateURI := viper.GetString("template")
+	base := viper.GetString("base")
+	overlays := splitList(viper.GetStringSlice("overlay"))
 	s3Bucket := viper.GetString("s3-bucket")
 	s3ConfigKey := viper.GetString("s3-config-key")
 	output := viper.GetString("output")
+	format := viper.GetString("format")
+	envOutput := viper.GetString("env-output")
+	envTemplate := viper.GetString("env-template")
+	envQuote := viper.GetString("env-quote")
+	validateOnly := viper.GetBool("validate-only")
+	tokenPermissions := viper.GetString("token-permissions")
+	tokenHandoff := viper.GetString("token-handoff")
+	tokenFile := viper.GetString("token-file")
 
-	privateKey, err := parsePrivateKey(pemSecretArn)
+	var renders []processor.RenderTarget
+	for _, value := range viper.GetStringSlice("render") {
+		target, err := processor.ParseRenderTarget(value)
+		if err != nil {
+			log.Fatal(err)
+		}
+		renders = append(renders, target)
+	}
+
+	fileMode, err := strconv.ParseUint(viper.GetString("file-mode"), 8, 32)
+	if err != nil {
+		log.Fatalf("Invalid file mode: %v", err)
+	}
+
+	secretFileMode, err := strconv.ParseUint(viper.GetString("secret-file-mode"), 8, 32)
+	if err != nil {
+		log.Fatalf("Invalid secret file mode: %v", err)
+	}
+
+	var hostRules []hostrules.Config
+	err = viper.UnmarshalKey("hostRules", &hostRules)
+	if err != nil {
+		log.Fatalf("Error reading host rules: %v", err)
+	}
+
+	httpClient := newHTTPClient()
+	awsClients := initAWSClients(httpClient)
+	providers := newSecretProviders(awsClients, httpClient)
+	githubConfig, err := parseGitHubConfig(awsClients, providers, httpClient)
 	if err != nil {
-		fmt.Printf("Error retrieving private key: %v\n", err)
-		return
+		log.Fatalf("Error retrieving private key: %v", err)
 	}
 
-	githubConfig := &processor.GitHubConfig{
-		ApplicationID: appId,
-		PrivateKey:    privateKey,
-		Endpoint:      githubEndpoint,
+	gitHubCom, err := parseGitHubComConfig(providers, httpClient)
+	if err != nil {
+		log.Fatalf("Error retrieving github.com credentials: %v", err)
 	}
 
 	options := processor.GenerateCommandOptions{
 		InstallationID:   installationId,
 		TargetRepository: targetRepository,
+		Mode:             mode,
+		Template:         templateURI,
+		Base:             base,
+		Overlays:         overlays,
 		Output:           output,
+		Format:           format,
+		EnvOutput:        envOutput,
+		EnvTemplate:      envTemplate,
+		EnvQuote:         envQuote,
+		Renders:          renders,
+		FileMode:         os.FileMode(fileMode),
+		SecretFileMode:   os.FileMode(secretFileMode),
+		ValidateOnly:     validateOnly,
 		S3Bucket:         s3Bucket,
 		S3ConfigKey:      s3ConfigKey,
+		TokenPermissions: tokenPermissions,
+		TokenHandoff:     tokenHandoff,
+		TokenFile:        tokenFile,
+		HostRules:        hostRules,
+		GitHubCom:        gitHubCom,
+		AWS:              awsClients,
+		Secrets:          providers,
 	}
 
 	err = processor.Generate(githubConfig, options)
@@ -50,3 +109,42 @@ func generateConfigCommand(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	}
 }
+
+func parseGitHubComConfig(providers secrets.Providers, httpClient *http.Client) (*processor.GitHubComConfig, error) {
+	config := &processor.GitHubComConfig{
+		ApplicationID:  viper.GetString("github-com-app-id"),
+		InstallationID: viper.GetInt64("github-com-installation-id"),
+		HTTPClient:     httpClient,
+	}
+
+	if tokenSecret := viper.GetString("github-com-token-aws-secret"); tokenSecret != "" {
+		token, err := providers.GetSecret(tokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		config.Token = strings.TrimSpace(token)
+	}
+
+	if config.ApplicationID != "" {
+		privateKey, err := parsePrivateKey(providers, viper.GetString("github-com-pem-aws-secret"))
+		if err != nil {
+			return nil, err
+		}
+		config.PrivateKey = privateKey
+	}
+
+	return config, nil
+}
+
+func splitList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
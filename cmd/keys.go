@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/processor"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage GitHub Application keys",
+}
+
+var keysCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check configured keys",
+	Long:  `Verify every configured GitHub Application key against GitHub`,
+	Run:   keysCheckCommand,
+}
+
+func keysCheckCommand(cmd *cobra.Command, args []string) {
+	httpClient := newHTTPClient()
+	awsClients := initAWSClients(httpClient)
+	githubConfig, err := parseGitHubConfig(awsClients, newSecretProviders(awsClients, httpClient), httpClient)
+	if err != nil {
+		log.Fatalf("Error retrieving private key: %v", err)
+	}
+
+	results, err := processor.CheckKeys(githubConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			failed = true
+			fmt.Printf("%s\tFAILED\t%v\n", result.KeyID, result.Err)
+			continue
+		}
+		fmt.Printf("%s\tOK\t%s\n", result.KeyID, result.ApplicationSlug)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/coding-ia/renovate-controller/internal/processor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"log"
+)
+
+var revokeTokenCmd = &cobra.Command{
+	Use:   "revoke-token",
+	Short: "Revoke installation token",
+	Long:  `Revoke the installation token written by generate-config once Renovate has finished`,
+	Run:   revokeTokenCommand,
+}
+
+func revokeTokenCommand(cmd *cobra.Command, args []string) {
+	githubEndpoint := viper.GetString("endpoint")
+	tokenFile := viper.GetString("token-file")
+
+	err := processor.RevokeToken(githubEndpoint, tokenFile, newHTTPClient())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"github.com/coding-ia/renovate-controller/internal/httpclient"
+	"github.com/coding-ia/renovate-controller/internal/processor"
+	"github.com/coding-ia/renovate-controller/internal/renovate"
+	"github.com/coding-ia/renovate-controller/internal/service"
 	"github.com/spf13/viper"
 	"log"
 	"os"
@@ -20,17 +24,41 @@ to quickly create a Cobra application.`,
 }
 
 func Execute() {
+	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().String("config", "", "Controller config file (YAML or JSON)")
+	mapEnvToPFlag(rootCmd, "config", "RENOVATE_CONTROLLER_CONFIG")
+
 	taskCmd.PersistentFlags().StringP("appId", "a", "", "GitHub Installation Application ID")
 	taskCmd.PersistentFlags().StringP("pem-aws-secret", "s", "", "GitHub Application Private Key (Secrets Manager)")
-	taskCmd.PersistentFlags().StringP("endpoint", "e", "", "GitHub Endpoint")
+	taskCmd.PersistentFlags().String("private-key", "", "GitHub Application Private Key URI (file://, env://, awssm://, ssm://, vault://, k8s://)")
+	taskCmd.PersistentFlags().Bool("all-key-versions", false, "Try the AWSCURRENT and AWSPENDING versions of Secrets Manager private keys")
+	taskCmd.PersistentFlags().String("kms-key-id", "", "AWS KMS key used to sign the GitHub Application JWT instead of a private key")
+	taskCmd.PersistentFlags().StringP("endpoint", "e", "", "GitHub API endpoint (GHES host or full API URL, defaults to github.com)")
+	taskCmd.PersistentFlags().String("ca-bundle", "", "PEM CA bundle trusted by the GitHub and AWS clients")
+	taskCmd.PersistentFlags().String("proxy", "", "Proxy URL for the GitHub and AWS clients (defaults to HTTPS_PROXY, honors NO_PROXY)")
+	taskCmd.PersistentFlags().String("client-cert", "", "PEM client certificate for mutual TLS")
+	taskCmd.PersistentFlags().String("client-key", "", "PEM client key for mutual TLS")
+	taskCmd.PersistentFlags().String("token-file", "", "Installation token file shared with revoke-token (file handoff defaults to 'token' next to the output)")
 
 	mapEnvToPFlag(taskCmd, "appId", "GITHUB_APPLICATION_ID")
 	mapEnvToPFlag(taskCmd, "pem-aws-secret", "GITHUB_APPLICATION_PRIVATE_PEM_AWS_SECRET")
+	mapEnvToPFlag(taskCmd, "private-key", "GITHUB_APPLICATION_PRIVATE_KEY")
+	mapEnvToPFlag(taskCmd, "all-key-versions", "GITHUB_APPLICATION_KEY_ALL_VERSIONS")
+	mapEnvToPFlag(taskCmd, "kms-key-id", "GITHUB_APPLICATION_KMS_KEY_ID")
 	mapEnvToPFlag(taskCmd, "endpoint", "GITHUB_APPLICATION_ENDPOINT")
+	mapEnvToPFlag(taskCmd, "ca-bundle", httpclient.EnvCABundle)
+	mapEnvToPFlag(taskCmd, "proxy", httpclient.EnvProxy)
+	mapEnvToPFlag(taskCmd, "client-cert", httpclient.EnvClientCert)
+	mapEnvToPFlag(taskCmd, "client-key", httpclient.EnvClientKey)
+	mapEnvToPFlag(taskCmd, "token-file", "GENERATE_CONFIG_TOKEN_FILE")
 
 	runCmd.Flags().StringP("cluster", "c", "", "ECS Cluster Name")
 	runCmd.Flags().StringP("task", "t", "", "Task Definition Name")
 	runCmd.Flags().String("container-name", "renovate", "Task Container Name")
+	runCmd.Flags().String("task-ca-bundle", "", "CA bundle path inside the launched task (defaults to --ca-bundle)")
+	runCmd.Flags().String("task-client-cert", "", "Client certificate path inside the launched task (defaults to --client-cert)")
+	runCmd.Flags().String("task-client-key", "", "Client key path inside the launched task (defaults to --client-key)")
 	runCmd.Flags().String("subnet-ids", "", "AWS VPC Subnet IDs")
 	runCmd.Flags().String("security-group-ids", "", "AWS VPC SecurityGroup IDs")
 	runCmd.Flags().Bool("assign-public-ip", false, "Assign Public IP to Task")
@@ -41,21 +69,70 @@ func Execute() {
 	mapEnvToFlag(runCmd, "subnet-ids", "AWS_ECS_TASK_SUBNET_IDS")
 	mapEnvToFlag(runCmd, "security-group-ids", "AWS_ECS_TASK_SECURITY_GROUP_IDS")
 	mapEnvToFlag(runCmd, "assign-public-ip", "AWS_ECS_TASK_PUBLIC_IP")
+	mapEnvToFlag(runCmd, "task-ca-bundle", "RENOVATE_CONTROLLER_TASK_CA_BUNDLE")
+	mapEnvToFlag(runCmd, "task-client-cert", "RENOVATE_CONTROLLER_TASK_CLIENT_CERT")
+	mapEnvToFlag(runCmd, "task-client-key", "RENOVATE_CONTROLLER_TASK_CLIENT_KEY")
 
 	generateConfigCmd.Flags().Int64P("installationId", "", 0, "GitHub Installation ID")
 	generateConfigCmd.Flags().StringP("target-repository", "", "", "GitHub target repository")
+	generateConfigCmd.Flags().String("mode", processor.ModeTemplate, "Config generation mode (template, structured)")
+	generateConfigCmd.Flags().String("base", "", "Base config URI for structured mode (YAML or JSON)")
+	generateConfigCmd.Flags().StringSlice("overlay", nil, "Overlay config URIs for structured mode, applied in order")
+	generateConfigCmd.Flags().String("template", "", "Renovate config template URI (s3://, file://, ssm://, github://, https://)")
 	generateConfigCmd.Flags().StringP("s3-bucket", "", "", "Renovate config (AWS S3 Bucket)")
 	generateConfigCmd.Flags().StringP("s3-config-key", "", "", "Renovate config file (AWS S3 Bucket Key)")
-	generateConfigCmd.Flags().StringP("output", "o", "config.ts", "Config file")
+	generateConfigCmd.Flags().StringP("output", "o", "config.ts", "Config file ('-' for stdout)")
+	generateConfigCmd.Flags().String("format", processor.FormatConfig, "Output format (config, env)")
+	generateConfigCmd.Flags().String("env-output", "", "Also write a Renovate env file to this path")
+	generateConfigCmd.Flags().String("env-template", "", "Env template URI whose KEY=VALUE lines are added to the env file")
+	generateConfigCmd.Flags().String("env-quote", renovate.EnvQuoteShell, "Env file quoting style (shell, dotenv)")
+	generateConfigCmd.Flags().StringArray("render", nil, "Additional <template>=<output> pairs to render")
+	generateConfigCmd.Flags().String("file-mode", "0600", "File mode of generated files")
+	generateConfigCmd.Flags().String("secret-file-mode", "0600", "File mode of the token file and env files containing the token")
+	generateConfigCmd.Flags().Bool("validate-only", false, "Render and validate the config without writing it")
+	generateConfigCmd.Flags().String("token-permissions", service.DefaultTokenPermissions, "Installation token permissions when a target repository is set")
+	generateConfigCmd.Flags().String("token-handoff", processor.TokenHandoffInline, "How the installation token is handed to Renovate (inline, env, file)")
+	generateConfigCmd.Flags().String("github-com-token-aws-secret", "", "github.com token for release notes (Secrets Manager)")
+	generateConfigCmd.Flags().String("github-com-app-id", "", "github.com Application ID for release notes")
+	generateConfigCmd.Flags().String("github-com-pem-aws-secret", "", "github.com Application Private Key (Secrets Manager)")
+	generateConfigCmd.Flags().Int64("github-com-installation-id", 0, "github.com Installation ID (defaults to the first installation)")
 
 	mapEnvToFlag(generateConfigCmd, "installationId", "GITHUB_INSTALLATION_ID")
 	mapEnvToFlag(generateConfigCmd, "target-repository", "GITHUB_TARGET_REPOSITORY")
+	mapEnvToFlag(generateConfigCmd, "mode", "GENERATE_CONFIG_MODE")
+	mapEnvToFlag(generateConfigCmd, "base", "CONFIG_BASE_URI")
+	mapEnvToFlag(generateConfigCmd, "overlay", "CONFIG_OVERLAY_URIS")
+	mapEnvToFlag(generateConfigCmd, "template", "CONFIG_TEMPLATE_URI")
 	mapEnvToFlag(generateConfigCmd, "s3-bucket", "CONFIG_TEMPLATE_BUCKET")
 	mapEnvToFlag(generateConfigCmd, "s3-config-key", "CONFIG_TEMPLATE_KEY")
 	mapEnvToFlag(generateConfigCmd, "output", "GENERATE_CONFIG_OUTPUT")
+	mapEnvToFlag(generateConfigCmd, "format", "GENERATE_CONFIG_FORMAT")
+	mapEnvToFlag(generateConfigCmd, "env-output", "GENERATE_CONFIG_ENV_OUTPUT")
+	mapEnvToFlag(generateConfigCmd, "env-template", "GENERATE_CONFIG_ENV_TEMPLATE")
+	mapEnvToFlag(generateConfigCmd, "env-quote", "GENERATE_CONFIG_ENV_QUOTE")
+	mapEnvToFlag(generateConfigCmd, "render", "GENERATE_CONFIG_RENDER")
+	mapEnvToFlag(generateConfigCmd, "file-mode", "GENERATE_CONFIG_FILE_MODE")
+	mapEnvToFlag(generateConfigCmd, "secret-file-mode", "GENERATE_CONFIG_SECRET_FILE_MODE")
+	mapEnvToFlag(generateConfigCmd, "github-com-token-aws-secret", "GITHUB_COM_TOKEN_AWS_SECRET")
+	mapEnvToFlag(generateConfigCmd, "github-com-app-id", "GITHUB_COM_APPLICATION_ID")
+	mapEnvToFlag(generateConfigCmd, "github-com-pem-aws-secret", "GITHUB_COM_APPLICATION_PRIVATE_PEM_AWS_SECRET")
+	mapEnvToFlag(generateConfigCmd, "github-com-installation-id", "GITHUB_COM_INSTALLATION_ID")
+	mapEnvToFlag(generateConfigCmd, "validate-only", "GENERATE_CONFIG_VALIDATE_ONLY")
+	mapEnvToFlag(generateConfigCmd, "token-permissions", "GITHUB_TOKEN_PERMISSIONS")
+	mapEnvToFlag(generateConfigCmd, "token-handoff", "GENERATE_CONFIG_TOKEN_HANDOFF")
+
+	cleanupCmd.Flags().StringSlice("path", nil, "Files to scrub and remove (the token file is revoked first)")
+	cleanupCmd.Flags().Duration("delay", 0, "Time to wait before cleaning up, e.g. until Renovate has loaded its config")
+
+	mapEnvToFlag(cleanupCmd, "path", "CLEANUP_PATHS")
+	mapEnvToFlag(cleanupCmd, "delay", "CLEANUP_DELAY")
 
 	taskCmd.AddCommand(runCmd)
 	taskCmd.AddCommand(generateConfigCmd)
+	taskCmd.AddCommand(cleanupCmd)
+	taskCmd.AddCommand(revokeTokenCmd)
+	keysCmd.AddCommand(keysCheckCmd)
+	taskCmd.AddCommand(keysCmd)
 	rootCmd.AddCommand(taskCmd)
 
 	err := rootCmd.Execute()
@@ -64,6 +141,19 @@ func Execute() {
 	}
 }
 
+func initConfig() {
+	configFile := viper.GetString("config")
+	if configFile == "" {
+		return
+	}
+
+	viper.SetConfigFile(configFile)
+	err := viper.ReadInConfig()
+	if err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+}
+
 func mapEnvToFlag(command *cobra.Command, flag string, env string) {
 	err := viper.BindPFlag(flag, command.Flags().Lookup(flag))
 	if err != nil {
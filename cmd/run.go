@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/awsclient"
 	"github.com/coding-ia/renovate-controller/internal/processor"
 	"github.com/coding-ia/renovate-controller/internal/secrets"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"log"
+	"net/http"
 	"strings"
 )
 
@@ -19,17 +21,18 @@ var runCmd = &cobra.Command{
 
 func runCommand(cmd *cobra.Command, args []string) {
 	appId := viper.GetString("appId")
-	pemSecretArn := viper.GetString("pem-aws-secret")
+	pemSecretArn := privateKeyReference()
 	githubEndpoint := viper.GetString("endpoint")
 	containerName := viper.GetString("container-name")
 	subnets := viper.GetString("subnet-ids")
 	securityGroups := viper.GetString("security-group-ids")
 	publicIP := viper.GetBool("assign-public-ip")
 
-	privateKey, err := parsePrivateKey(pemSecretArn)
+	httpClient := newHTTPClient()
+	awsClients := initAWSClients(httpClient)
+	githubConfig, err := parseGitHubConfig(awsClients, newSecretProviders(awsClients, httpClient), httpClient)
 	if err != nil {
-		fmt.Printf("Error retrieving private key: %v\n", err)
-		return
+		log.Fatalf("Error retrieving private key: %v", err)
 	}
 
 	task := viper.GetString("task")
@@ -52,6 +55,12 @@ func runCommand(cmd *cobra.Command, args []string) {
 		AssignPublicIP: publicIP,
 		Subnets:        subnetsSlice,
 		SecurityGroups: securityGroupsSlice,
+		HTTPConfig:     parseHTTPConfig(),
+		TaskCABundle:   viper.GetString("task-ca-bundle"),
+		TaskClientCert: viper.GetString("task-client-cert"),
+		TaskClientKey:  viper.GetString("task-client-key"),
+		ECS:            awsClients.ECS,
+		EC2:            awsClients.EC2,
 		TaskOptions: processor.TaskCommandOptions{
 			ApplicationID: appId,
 			PEMAWSSecret:  pemSecretArn,
@@ -59,20 +68,88 @@ func runCommand(cmd *cobra.Command, args []string) {
 		},
 	}
 
-	githubConfig := &processor.GitHubConfig{
-		ApplicationID: appId,
-		PrivateKey:    privateKey,
-		Endpoint:      githubEndpoint,
-	}
-
 	err = processor.Run(githubConfig, runConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func parsePrivateKey(pemSecretArn string) ([]byte, error) {
-	secret, err := secrets.GetSecret(pemSecretArn)
+func privateKeyReference() string {
+	if privateKey := viper.GetString("private-key"); privateKey != "" {
+		return privateKey
+	}
+	return viper.GetString("pem-aws-secret")
+}
+
+// parseGitHubConfig loads every configured application key. private-key and
+// pem-aws-secret accept a comma separated list which is tried in order.
+func parseGitHubConfig(awsClients *awsclient.Clients, providers secrets.Providers, httpClient *http.Client) (*processor.GitHubConfig, error) {
+	githubConfig := &processor.GitHubConfig{
+		ApplicationID: viper.GetString("appId"),
+		KMSKeyID:      viper.GetString("kms-key-id"),
+		KMS:           awsClients.KMS,
+		Endpoint:      viper.GetString("endpoint"),
+		HTTPClient:    httpClient,
+	}
+
+	refs := splitList([]string{privateKeyReference()})
+	if githubConfig.KMSKeyID != "" && len(refs) == 0 {
+		return githubConfig, nil
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no private key configured")
+	}
+
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		versions := []string{ref}
+		if viper.GetBool("all-key-versions") {
+			versions = secretVersionReferences(ref)
+		}
+
+		for i, version := range versions {
+			privateKey, err := parsePrivateKey(providers, version)
+			if err != nil && i > 0 {
+				log.Printf("Skipping key '%s': %v", version, err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if seen[string(privateKey)] {
+				continue
+			}
+			seen[string(privateKey)] = true
+			githubConfig.PrivateKeys = append(githubConfig.PrivateKeys, privateKey)
+		}
+	}
+
+	return githubConfig, nil
+}
+
+// secretVersionReferences expands a Secrets Manager reference without an
+// explicit version into its AWSCURRENT and AWSPENDING versions.
+func secretVersionReferences(ref string) []string {
+	parsed, err := secrets.ParseReference(ref)
+	if err != nil || parsed.Scheme != "awssm" || parsed.Query.Has("versionStage") || parsed.Query.Has("versionId") {
+		return []string{ref}
+	}
+
+	separator := "?"
+	if strings.Contains(ref, "?") {
+		separator = "&"
+	}
+	if !strings.Contains(ref, "://") {
+		ref = "awssm://" + ref
+	}
+	return []string{
+		ref + separator + "versionStage=AWSCURRENT",
+		ref + separator + "versionStage=AWSPENDING",
+	}
+}
+
+func parsePrivateKey(providers secrets.Providers, pemSecretArn string) ([]byte, error) {
+	secret, err := providers.GetSecret(pemSecretArn)
 	if err != nil {
 		return nil, err
 	}
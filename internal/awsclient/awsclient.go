@@ -0,0 +1,77 @@
+package awsclient
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/codeartifact"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"net/http"
+)
+
+// Clients holds the AWS service clients used by the controller. They are
+// built from one config so credentials and region are resolved only once.
+type Clients struct {
+	Config         aws.Config
+	ECS            *ecs.Client
+	EC2            *ec2.Client
+	S3             *s3.Client
+	SecretsManager *secretsmanager.Client
+	SSM            *ssm.Client
+	KMS            *kms.Client
+	ECR            *ecr.Client
+	CodeArtifact   *codeartifact.Client
+}
+
+func New(cfg aws.Config) *Clients {
+	return &Clients{
+		Config:         cfg,
+		ECS:            ecs.NewFromConfig(cfg),
+		EC2:            ec2.NewFromConfig(cfg),
+		S3:             s3.NewFromConfig(cfg),
+		SecretsManager: secretsmanager.NewFromConfig(cfg),
+		SSM:            ssm.NewFromConfig(cfg),
+		KMS:            kms.NewFromConfig(cfg),
+		ECR:            ecr.NewFromConfig(cfg),
+		CodeArtifact:   codeartifact.NewFromConfig(cfg),
+	}
+}
+
+// Load builds the clients from the default config. A non-nil httpClient
+// supplies the proxy and TLS settings.
+func Load(ctx context.Context, httpClient *http.Client) (*Clients, error) {
+	var opts []func(*config.LoadOptions) error
+	if httpClient != nil {
+		opts = append(opts, config.WithHTTPClient(newHTTPClient(httpClient.Transport)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(cfg), nil
+}
+
+// newHTTPClient keeps the SDK's client defaults and only takes over proxy
+// and TLS settings of the configured transport.
+func newHTTPClient(transport http.RoundTripper) config.HTTPClient {
+	base, ok := transport.(*http.Transport)
+	if !ok {
+		return &http.Client{Transport: transport}
+	}
+
+	return awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		t.Proxy = base.Proxy
+		if base.TLSClientConfig != nil {
+			t.TLSClientConfig = base.TLSClientConfig.Clone()
+		}
+	})
+}
@@ -0,0 +1,455 @@
+// Package githubtest provides a fake GitHub App API for exercising the
+// controller end-to-end without talking to GitHub.
+package githubtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultApplicationID = "12345"
+	DefaultSlug          = "renovate-controller"
+	DefaultPathPrefix    = "/api/v3"
+	botUserID            = 41898282
+)
+
+type Repository struct {
+	Owner         string
+	OwnerType     string
+	Name          string
+	DefaultBranch string
+	Private       bool
+	Topics        []string
+}
+
+func (r Repository) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+type Installation struct {
+	ID           int64
+	Account      string
+	Repositories []Repository
+}
+
+type tokenGrant struct {
+	InstallationID int64
+	Repositories   []string
+	ExpiresAt      time.Time
+}
+
+// Server emulates the GitHub App endpoints used by the controller. Requests
+// are served both at the root and below PathPrefix, so it can stand in for
+// github.com as well as for a GHES "/api/v3/" API.
+type Server struct {
+	*httptest.Server
+
+	ApplicationID string
+	Slug          string
+	PrivateKey    *rsa.PrivateKey
+	PathPrefix    string
+	Installations []Installation
+
+	mu       sync.Mutex
+	tokens   map[string]tokenGrant
+	requests []string
+}
+
+// NewServer starts a TLS server with a freshly generated app key. Clients
+// have to trust it, e.g. by passing CertificatePEM as CA bundle or by
+// sending their requests with Server.Client().
+func NewServer(installations ...Installation) (*Server, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		ApplicationID: DefaultApplicationID,
+		Slug:          DefaultSlug,
+		PrivateKey:    privateKey,
+		PathPrefix:    DefaultPathPrefix,
+		Installations: installations,
+		tokens:        map[string]tokenGrant{},
+	}
+	s.Server = httptest.NewTLSServer(s.handler())
+	return s, nil
+}
+
+// Endpoint returns the host as accepted by the --endpoint flag.
+func (s *Server) Endpoint() string {
+	u, _ := url.Parse(s.URL)
+	return u.Host
+}
+
+func (s *Server) CertificatePEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	})
+}
+
+func (s *Server) PrivateKeyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(s.PrivateKey),
+	})
+}
+
+// Requests returns the "METHOD path" of every request served so far, with
+// PathPrefix removed.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// ActiveTokens returns the number of issued installation tokens that have
+// not been revoked.
+func (s *Server) ActiveTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app", s.requireJWT(s.getApp))
+	mux.HandleFunc("GET /app/installations", s.requireJWT(s.listInstallations))
+	mux.HandleFunc("GET /app/installations/{id}", s.requireJWT(s.getInstallation))
+	mux.HandleFunc("POST /app/installations/{id}/access_tokens", s.requireJWT(s.createToken))
+	mux.HandleFunc("GET /installation/repositories", s.requireToken(s.listRepositories))
+	mux.HandleFunc("DELETE /installation/token", s.requireToken(s.revokeToken))
+	mux.HandleFunc("GET /users/{login}", s.requireToken(s.getUser))
+	mux.HandleFunc("GET /repos/{owner}/{repo}/properties/values", s.requireToken(s.getCustomProperties))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.PathPrefix != "" && strings.HasPrefix(r.URL.Path, s.PathPrefix+"/") {
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, s.PathPrefix)
+			r.URL.RawPath = ""
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// requireJWT verifies the app JWT against the server's key, the way GitHub
+// does for /app endpoints.
+func (s *Server) requireJWT(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString, ok := bearerToken(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "A JSON web token could not be decoded")
+			return
+		}
+
+		claims := jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+			return &s.PrivateKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(s.ApplicationID), jwt.WithIssuedAt())
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, fmt.Sprintf("A JSON web token could not be decoded: %v", err))
+			return
+		}
+		if claims.ExpiresAt == nil || claims.IssuedAt == nil || claims.ExpiresAt.Sub(claims.IssuedAt.Time) > 11*time.Minute {
+			writeError(w, http.StatusUnauthorized, "'Expiration time' claim ('exp') is too far in the future")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Requires authentication")
+			return
+		}
+
+		s.mu.Lock()
+		grant, found := s.tokens[token]
+		s.mu.Unlock()
+		if !found || time.Now().After(grant.ExpiresAt) {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) getApp(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(s.ApplicationID, 10, 64)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":   id,
+		"slug": s.Slug,
+		"name": s.Slug,
+	})
+}
+
+func (s *Server) listInstallations(w http.ResponseWriter, r *http.Request) {
+	var installations []interface{}
+	for _, installation := range s.Installations {
+		installations = append(installations, s.installationJSON(installation))
+	}
+
+	page, hasNext := paginate(installations, r)
+	s.writePage(w, r, hasNext)
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) getInstallation(w http.ResponseWriter, r *http.Request) {
+	installation, ok := s.findInstallation(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.installationJSON(installation))
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	installation, ok := s.findInstallation(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	var options struct {
+		Repositories []string `json:"repositories"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+			writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+			return
+		}
+	}
+
+	var scoped []string
+	for _, name := range options.Repositories {
+		found := false
+		for _, repo := range installation.Repositories {
+			if strings.EqualFold(repo.Name, name) {
+				scoped = append(scoped, repo.FullName())
+				found = true
+			}
+		}
+		if !found {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("There is at least one repository that does not exist or is not accessible to the parent installation: %s", name))
+			return
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	grant := tokenGrant{
+		InstallationID: installation.ID,
+		Repositories:   scoped,
+		ExpiresAt:      time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	s.mu.Lock()
+	s.tokens[token] = grant
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":      token,
+		"expires_at": grant.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request) {
+	grant := s.grant(r)
+	installation, _ := s.findInstallation(strconv.FormatInt(grant.InstallationID, 10))
+
+	var repositories []interface{}
+	for _, repo := range installation.Repositories {
+		if len(grant.Repositories) > 0 && !slices.Contains(grant.Repositories, repo.FullName()) {
+			continue
+		}
+		repositories = append(repositories, s.repositoryJSON(repo))
+	}
+
+	page, hasNext := paginate(repositories, r)
+	s.writePage(w, r, hasNext)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count":  len(repositories),
+		"repositories": page,
+	})
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	login := r.PathValue("login")
+	if login != s.Slug+"[bot]" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"login": login,
+		"id":    botUserID,
+		"type":  "Bot",
+	})
+}
+
+func (s *Server) getCustomProperties(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []interface{}{})
+}
+
+func (s *Server) grant(r *http.Request) tokenGrant {
+	token, _ := bearerToken(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[token]
+}
+
+func (s *Server) findInstallation(id string) (Installation, bool) {
+	for _, installation := range s.Installations {
+		if strconv.FormatInt(installation.ID, 10) == id {
+			return installation, true
+		}
+	}
+	return Installation{}, false
+}
+
+func (s *Server) installationJSON(installation Installation) map[string]interface{} {
+	appID, _ := strconv.ParseInt(s.ApplicationID, 10, 64)
+	return map[string]interface{}{
+		"id":     installation.ID,
+		"app_id": appID,
+		"account": map[string]interface{}{
+			"login": installation.Account,
+		},
+	}
+}
+
+func (s *Server) repositoryJSON(repo Repository) map[string]interface{} {
+	ownerType := repo.OwnerType
+	if ownerType == "" {
+		ownerType = "Organization"
+	}
+	defaultBranch := repo.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = "main"
+	}
+	visibility := "public"
+	if repo.Private {
+		visibility = "private"
+	}
+
+	return map[string]interface{}{
+		"name":      repo.Name,
+		"full_name": repo.FullName(),
+		"owner": map[string]interface{}{
+			"login": repo.Owner,
+			"type":  ownerType,
+		},
+		"default_branch": defaultBranch,
+		"private":        repo.Private,
+		"visibility":     visibility,
+		"topics":         repo.Topics,
+	}
+}
+
+// writePage adds the Link header go-github reads the next page from.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, hasNext bool) {
+	if !hasNext {
+		return
+	}
+
+	page, perPage := pageParams(r)
+	next := *r.URL
+	next.Scheme = "https"
+	next.Host = r.Host
+	next.Path = s.PathPrefix + r.URL.Path
+	query := next.Query()
+	query.Set("page", strconv.Itoa(page+1))
+	query.Set("per_page", strconv.Itoa(perPage))
+	next.RawQuery = query.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
+
+func paginate(items []interface{}, r *http.Request) ([]interface{}, bool) {
+	page, perPage := pageParams(r)
+
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return []interface{}{}, false
+	}
+	end := min(start+perPage, len(items))
+	return items[start:end], end < len(items)
+}
+
+func pageParams(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 30
+	}
+	return page, perPage
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "token")) {
+		return "", false
+	}
+	return token, true
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "ghs_" + hex.EncodeToString(buf), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
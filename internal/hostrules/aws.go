@@ -0,0 +1,87 @@
+package hostrules
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codeartifact"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"strings"
+)
+
+type ECRAPI interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+}
+
+type CodeArtifactAPI interface {
+	GetAuthorizationToken(ctx context.Context, params *codeartifact.GetAuthorizationTokenInput, optFns ...func(*codeartifact.Options)) (*codeartifact.GetAuthorizationTokenOutput, error)
+}
+
+type ECRSource struct {
+	RegistryID string `mapstructure:"registryId"`
+	Region     string `mapstructure:"region"`
+}
+
+type CodeArtifactSource struct {
+	Domain      string `mapstructure:"domain"`
+	DomainOwner string `mapstructure:"domainOwner"`
+	Region      string `mapstructure:"region"`
+}
+
+func ecrCredentials(ctx context.Context, client ECRAPI, source ECRSource) (string, string, error) {
+	if client == nil {
+		return "", "", fmt.Errorf("no ECR client configured")
+	}
+
+	input := &ecr.GetAuthorizationTokenInput{}
+	if source.RegistryID != "" {
+		input.RegistryIds = []string{source.RegistryID}
+	}
+
+	output, err := client.GetAuthorizationToken(ctx, input, func(o *ecr.Options) {
+		if source.Region != "" {
+			o.Region = source.Region
+		}
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(output.AuthorizationData) == 0 {
+		return "", "", fmt.Errorf("ECR returned no authorization data")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(aws.ToString(output.AuthorizationData[0].AuthorizationToken))
+	if err != nil {
+		return "", "", err
+	}
+
+	username, password, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", fmt.Errorf("unexpected ECR authorization token format")
+	}
+	return username, password, nil
+}
+
+func codeArtifactToken(ctx context.Context, client CodeArtifactAPI, source CodeArtifactSource) (string, error) {
+	if client == nil {
+		return "", fmt.Errorf("no CodeArtifact client configured")
+	}
+
+	input := &codeartifact.GetAuthorizationTokenInput{
+		Domain: aws.String(source.Domain),
+	}
+	if source.DomainOwner != "" {
+		input.DomainOwner = aws.String(source.DomainOwner)
+	}
+
+	output, err := client.GetAuthorizationToken(ctx, input, func(o *codeartifact.Options) {
+		if source.Region != "" {
+			o.Region = source.Region
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.AuthorizationToken), nil
+}
@@ -0,0 +1,116 @@
+package hostrules
+
+import (
+	"context"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
+	"github.com/coding-ia/renovate-controller/internal/store"
+)
+
+// Config describes a registry in the controller config and where its
+// credentials come from. Credentials are resolved at generate-config time.
+type Config struct {
+	MatchHost    string              `mapstructure:"matchHost"`
+	HostType     string              `mapstructure:"hostType"`
+	Username     *CredentialSource   `mapstructure:"username"`
+	Password     *CredentialSource   `mapstructure:"password"`
+	Token        *CredentialSource   `mapstructure:"token"`
+	ECR          *ECRSource          `mapstructure:"ecr"`
+	CodeArtifact *CodeArtifactSource `mapstructure:"codeArtifact"`
+}
+
+// CredentialSource reads a single value from a literal, a secret URI, a
+// Secrets Manager secret or an SSM parameter. Key selects a field of a JSON
+// secret.
+type CredentialSource struct {
+	Value          string `mapstructure:"value"`
+	Secret         string `mapstructure:"secret"`
+	SecretsManager string `mapstructure:"secretsManager"`
+	SSM            string `mapstructure:"ssm"`
+	Key            string `mapstructure:"key"`
+}
+
+// HostRule is a resolved Renovate hostRules entry.
+type HostRule struct {
+	MatchHost string `json:"matchHost"`
+	HostType  string `json:"hostType,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Token     string `json:"token,omitempty"`
+}
+
+// Clients are used by the credential sources that need one and may be nil
+// if no host rule uses them.
+type Clients struct {
+	ECR          ECRAPI
+	CodeArtifact CodeArtifactAPI
+	SSM          store.SSMAPI
+	Secrets      secrets.Providers
+}
+
+// Resolve reads the credentials of every host rule.
+func Resolve(ctx context.Context, configs []Config, clients Clients) ([]HostRule, error) {
+	rules := make([]HostRule, 0, len(configs))
+	for _, config := range configs {
+		rule, err := resolve(ctx, config, clients)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving host rule for '%s': %v", config.MatchHost, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func resolve(ctx context.Context, config Config, clients Clients) (HostRule, error) {
+	rule := HostRule{
+		MatchHost: config.MatchHost,
+		HostType:  config.HostType,
+	}
+	if rule.MatchHost == "" {
+		return rule, fmt.Errorf("matchHost is required")
+	}
+
+	var err error
+	switch {
+	case config.ECR != nil:
+		rule.Username, rule.Password, err = ecrCredentials(ctx, clients.ECR, *config.ECR)
+		return rule, err
+	case config.CodeArtifact != nil:
+		rule.Token, err = codeArtifactToken(ctx, clients.CodeArtifact, *config.CodeArtifact)
+		return rule, err
+	}
+
+	if rule.Username, err = config.Username.resolve(ctx, clients); err != nil {
+		return rule, err
+	}
+	if rule.Password, err = config.Password.resolve(ctx, clients); err != nil {
+		return rule, err
+	}
+	if rule.Token, err = config.Token.resolve(ctx, clients); err != nil {
+		return rule, err
+	}
+	return rule, nil
+}
+
+func (c *CredentialSource) resolve(ctx context.Context, clients Clients) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	var value string
+	var err error
+	switch {
+	case c.Secret != "":
+		value, err = clients.Secrets.Resolve(ctx, c.Secret)
+	case c.SecretsManager != "":
+		value, err = clients.Secrets.Resolve(ctx, c.SecretsManager)
+	case c.SSM != "":
+		value, err = store.GetSSMParameter(ctx, clients.SSM, c.SSM)
+	default:
+		value = c.Value
+	}
+	if err != nil || c.Key == "" {
+		return value, err
+	}
+	return secrets.ExtractKey(value, c.Key)
+}
@@ -0,0 +1,189 @@
+package hostrules
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codeartifact"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeECR struct {
+	token  string
+	region string
+	input  *ecr.GetAuthorizationTokenInput
+}
+
+func (f *fakeECR) GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error) {
+	options := ecr.Options{Region: "us-east-1"}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	f.region, f.input = options.Region, params
+
+	output := &ecr.GetAuthorizationTokenOutput{}
+	if f.token != "" {
+		output.AuthorizationData = []ecrtypes.AuthorizationData{{AuthorizationToken: aws.String(f.token)}}
+	}
+	return output, nil
+}
+
+type fakeCodeArtifact struct {
+	region string
+	input  *codeartifact.GetAuthorizationTokenInput
+}
+
+func (f *fakeCodeArtifact) GetAuthorizationToken(ctx context.Context, params *codeartifact.GetAuthorizationTokenInput, optFns ...func(*codeartifact.Options)) (*codeartifact.GetAuthorizationTokenOutput, error) {
+	options := codeartifact.Options{Region: "us-east-1"}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	f.region, f.input = options.Region, params
+
+	if aws.ToString(params.Domain) == "missing" {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &codeartifact.GetAuthorizationTokenOutput{AuthorizationToken: aws.String("ca-token")}, nil
+}
+
+type fakeSSM struct {
+	parameters map[string]string
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	value, found := f.parameters[aws.ToString(params.Name)]
+	if !found {
+		return nil, errors.New("ParameterNotFound")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(value)}}, nil
+}
+
+func TestResolveECR(t *testing.T) {
+	client := &fakeECR{token: base64.StdEncoding.EncodeToString([]byte("AWS:ecr-password"))}
+
+	rules, err := Resolve(context.Background(), []Config{
+		{MatchHost: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", HostType: "docker", ECR: &ECRSource{RegistryID: "123456789012", Region: "eu-west-1"}},
+	}, Clients{ECR: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []HostRule{{MatchHost: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", HostType: "docker", Username: "AWS", Password: "ecr-password"}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %+v, want %+v", rules, want)
+	}
+	if client.region != "eu-west-1" {
+		t.Errorf("region = %q, want eu-west-1", client.region)
+	}
+	if !reflect.DeepEqual(client.input.RegistryIds, []string{"123456789012"}) {
+		t.Errorf("registry IDs = %v", client.input.RegistryIds)
+	}
+}
+
+func TestResolveCodeArtifact(t *testing.T) {
+	client := &fakeCodeArtifact{}
+
+	rules, err := Resolve(context.Background(), []Config{
+		{MatchHost: "acme-123456789012.d.codeartifact.us-east-1.amazonaws.com", CodeArtifact: &CodeArtifactSource{Domain: "acme", DomainOwner: "123456789012"}},
+	}, Clients{CodeArtifact: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rules[0].Token != "ca-token" {
+		t.Errorf("token = %q, want ca-token", rules[0].Token)
+	}
+	if client.region != "us-east-1" {
+		t.Errorf("region = %q, want the client default", client.region)
+	}
+	if aws.ToString(client.input.Domain) != "acme" || aws.ToString(client.input.DomainOwner) != "123456789012" {
+		t.Errorf("input = %+v", client.input)
+	}
+}
+
+func TestResolveCredentialSources(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "npm.json")
+	if err := os.WriteFile(credentials, []byte(`{"username": "ci", "password": "hunter2", "port": 8443}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOSTRULES_TEST_TOKEN", "env-token")
+
+	clients := Clients{
+		SSM: &fakeSSM{parameters: map[string]string{
+			"/renovate/nexus": `{"token": "ssm-token"}`,
+			"/renovate/plain": "plain-token",
+		}},
+		Secrets: secrets.NewProviders(nil, nil, nil, nil),
+	}
+
+	tests := []struct {
+		name   string
+		source *CredentialSource
+		want   string
+	}{
+		{name: "nil", want: ""},
+		{name: "literal", source: &CredentialSource{Value: "literal"}, want: "literal"},
+		{name: "secret uri", source: &CredentialSource{Secret: "env://HOSTRULES_TEST_TOKEN"}, want: "env-token"},
+		{name: "secret uri key", source: &CredentialSource{Secret: "file://" + credentials + "?key=password"}, want: "hunter2"},
+		{name: "secret key", source: &CredentialSource{Secret: "file://" + credentials, Key: "username"}, want: "ci"},
+		{name: "non-string key", source: &CredentialSource{Secret: "file://" + credentials, Key: "port"}, want: "8443"},
+		{name: "ssm", source: &CredentialSource{SSM: "/renovate/plain"}, want: "plain-token"},
+		{name: "ssm key", source: &CredentialSource{SSM: "/renovate/nexus", Key: "token"}, want: "ssm-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.resolve(context.Background(), clients)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("resolve = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	clients := Clients{
+		ECR:          &fakeECR{token: base64.StdEncoding.EncodeToString([]byte("no-separator"))},
+		CodeArtifact: &fakeCodeArtifact{},
+		SSM:          &fakeSSM{parameters: map[string]string{"/renovate/plain": "plain-token"}},
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		clients Clients
+		err     string
+	}{
+		{name: "missing host", config: Config{Token: &CredentialSource{Value: "x"}}, clients: clients, err: "matchHost is required"},
+		{name: "no ECR client", config: Config{MatchHost: "ecr", ECR: &ECRSource{}}, err: "no ECR client configured"},
+		{name: "no ECR data", config: Config{MatchHost: "ecr", ECR: &ECRSource{}}, clients: Clients{ECR: &fakeECR{}}, err: "ECR returned no authorization data"},
+		{name: "bad ECR token", config: Config{MatchHost: "ecr", ECR: &ECRSource{}}, clients: clients, err: "unexpected ECR authorization token format"},
+		{name: "no CodeArtifact client", config: Config{MatchHost: "ca", CodeArtifact: &CodeArtifactSource{Domain: "acme"}}, err: "no CodeArtifact client configured"},
+		{name: "CodeArtifact error", config: Config{MatchHost: "ca", CodeArtifact: &CodeArtifactSource{Domain: "missing"}}, clients: clients, err: "ResourceNotFoundException"},
+		{name: "no SSM client", config: Config{MatchHost: "ssm", Token: &CredentialSource{SSM: "/renovate/plain"}}, err: "no SSM client configured"},
+		{name: "key of plain value", config: Config{MatchHost: "ssm", Token: &CredentialSource{SSM: "/renovate/plain", Key: "token"}}, clients: clients, err: "secret is not a JSON object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Resolve(context.Background(), []Config{tt.config}, tt.clients)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Resolve error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
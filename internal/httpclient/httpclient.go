@@ -0,0 +1,128 @@
+// Package httpclient builds the HTTP client shared by the GitHub, AWS, Vault
+// and template clients, adding a custom CA bundle, proxy and client
+// certificate.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"golang.org/x/net/http/httpproxy"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	EnvCABundle   = "RENOVATE_CONTROLLER_CA_BUNDLE"
+	EnvProxy      = "RENOVATE_CONTROLLER_PROXY"
+	EnvClientCert = "RENOVATE_CONTROLLER_CLIENT_CERT"
+	EnvClientKey  = "RENOVATE_CONTROLLER_CLIENT_KEY"
+)
+
+type Config struct {
+	CABundle   string
+	Proxy      string
+	ClientCert string
+	ClientKey  string
+}
+
+func (c Config) IsZero() bool {
+	return c == Config{}
+}
+
+// Transport returns a clone of http.DefaultTransport configured from c.
+// Without an explicit proxy HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used,
+// an explicit proxy still honors NO_PROXY.
+func (c Config) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.Proxy != "" {
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  c.Proxy,
+			HTTPSProxy: c.Proxy,
+			NoProxy:    noProxy(),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if c.CABundle == "" && c.ClientCert == "" {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle '%s'", c.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" {
+		if c.ClientKey == "" {
+			return nil, fmt.Errorf("client certificate requires a client key")
+		}
+		certificate, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// Client returns an HTTP client using the transport configured from c.
+func (c Config) Client() (*http.Client, error) {
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// ProxyEnvironment returns the proxy variables to hand to child processes:
+// the explicit proxy if set, otherwise the ones found in the environment.
+func ProxyEnvironment(proxy string) map[string]string {
+	environment := map[string]string{}
+	if proxy != "" {
+		environment["HTTPS_PROXY"] = proxy
+		environment["HTTP_PROXY"] = proxy
+	} else {
+		for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY"} {
+			if value := getenv(name); value != "" {
+				environment[name] = value
+			}
+		}
+	}
+
+	if value := noProxy(); value != "" && len(environment) > 0 {
+		environment["NO_PROXY"] = value
+	}
+	return environment
+}
+
+func getenv(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+func noProxy() string {
+	return getenv("NO_PROXY")
+}
@@ -0,0 +1,229 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"github.com/coding-ia/renovate-controller/internal/githubtest"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearProxyEnvironment unsets the proxy variables for the duration of t.
+func clearProxyEnvironment(t *testing.T) {
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		t.Setenv(name, "")
+		t.Setenv(strings.ToLower(name), "")
+	}
+}
+
+func TestClientCABundle(t *testing.T) {
+	server, err := githubtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	clearProxyEnvironment(t)
+	caBundle := writeFile(t, "ca.pem", server.CertificatePEM())
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "system roots only", config: Config{}, wantErr: true},
+		{name: "ca bundle", config: Config{CABundle: caBundle}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := tt.config.Client()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := client.Get(server.URL + "/api/v3/users/renovate-controller%5Bbot%5D")
+			if tt.wantErr {
+				var certErr x509.UnknownAuthorityError
+				if !errors.As(err, &certErr) {
+					t.Fatalf("error = %v, want an unknown authority error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	certificate, key := newClientCertificate(t)
+
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{name: "missing ca bundle", config: Config{CABundle: filepath.Join(t.TempDir(), "missing.pem")}, err: "error reading CA bundle"},
+		{name: "empty ca bundle", config: Config{CABundle: writeFile(t, "empty.pem", []byte("not a certificate"))}, err: "no certificates found"},
+		{name: "client certificate without key", config: Config{ClientCert: certificate}, err: "client certificate requires a client key"},
+		{name: "mismatched client key", config: Config{ClientCert: certificate, ClientKey: certificate}, err: "error loading client certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.Transport()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Transport error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+
+	transport, err := Config{ClientCert: certificate, ClientKey: key}.Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.TLSClientConfig == nil || len(transport.TLSClientConfig.Certificates) != 1 {
+		t.Errorf("client certificate was not configured")
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	clearProxyEnvironment(t)
+	t.Setenv("NO_PROXY", "internal.example.com,.corp.example.com")
+
+	transport, err := Config{Proxy: "http://proxy.example.com:3128"}.Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.github.com/app", want: "http://proxy.example.com:3128"},
+		{url: "http://ghes.example.com/api/v3/", want: "http://proxy.example.com:3128"},
+		{url: "https://internal.example.com/api/v3/", want: ""},
+		{url: "https://ghes.corp.example.com/api/v3/", want: ""},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != tt.want {
+			t.Errorf("proxy for %s = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProxyEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		proxy string
+		want  map[string]string
+	}{
+		{
+			name: "no proxy",
+			env:  map[string]string{"NO_PROXY": "internal.example.com"},
+			want: map[string]string{},
+		},
+		{
+			name:  "explicit proxy",
+			env:   map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "internal.example.com"},
+			proxy: "http://proxy:3128",
+			want: map[string]string{
+				"HTTPS_PROXY": "http://proxy:3128",
+				"HTTP_PROXY":  "http://proxy:3128",
+				"NO_PROXY":    "internal.example.com",
+			},
+		},
+		{
+			name: "environment",
+			env:  map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			want: map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+		},
+		{
+			name: "lower case environment",
+			env:  map[string]string{"https_proxy": "http://env-proxy:3128", "no_proxy": "localhost"},
+			want: map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "localhost"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearProxyEnvironment(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			if got := ProxyEnvironment(tt.proxy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProxyEnvironment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newClientCertificate writes a self-signed client certificate and its key
+// and returns their paths.
+func newClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "renovate-controller"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate := writeFile(t, "client.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyFile := writeFile(t, "client-key.pem", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certificate, keyFile
+}
@@ -0,0 +1,366 @@
+package json5
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse decodes a JSON5 document into generic Go values: map[string]interface{},
+// []interface{}, string, float64, bool and nil.
+func Parse(data []byte) (interface{}, error) {
+	p := &parser{data: string(data)}
+
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected %q after top-level value", p.peek())
+	}
+
+	return value, nil
+}
+
+func Valid(data []byte) error {
+	_, err := Parse(data)
+	return err
+}
+
+type parser struct {
+	data string
+	pos  int
+	err  error
+}
+
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("json5: %s at line %d, column %d", e.Msg, e.Line, e.Column)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(p.data[:p.pos], "\n")
+	column := p.pos - strings.LastIndex(p.data[:p.pos], "\n")
+	return &SyntaxError{Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() rune {
+	if p.pos >= len(p.data) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(p.data[p.pos:])
+	return r
+}
+
+func (p *parser) next() rune {
+	r, size := utf8.DecodeRuneInString(p.data[p.pos:])
+	p.pos += size
+	return r
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		r := p.peek()
+		switch {
+		case unicode.IsSpace(r) || r == '\uFEFF':
+			p.next()
+		case strings.HasPrefix(p.data[p.pos:], "//"):
+			end := strings.IndexAny(p.data[p.pos:], "\n\u2028\u2029")
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+		case strings.HasPrefix(p.data[p.pos:], "/*"):
+			end := strings.Index(p.data[p.pos+2:], "*/")
+			if end < 0 {
+				p.err = p.errorf("unterminated block comment")
+				p.pos = len(p.data)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch r := p.peek(); {
+	case r == '{':
+		return p.parseObject()
+	case r == '[':
+		return p.parseArray()
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '-' || r == '+' || r == '.' || (r >= '0' && r <= '9'):
+		return p.parseNumber()
+	case isIdentifierStart(r):
+		word := p.parseIdentifier()
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "NaN":
+			return math.NaN(), nil
+		}
+		return nil, p.errorf("unexpected identifier %q", word)
+	default:
+		return nil, p.errorf("unexpected %q", r)
+	}
+}
+
+func (p *parser) parseObject() (interface{}, error) {
+	p.next()
+	object := map[string]interface{}{}
+
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.next()
+			return object, nil
+		}
+
+		var key string
+		switch r := p.peek(); {
+		case r == '"' || r == '\'':
+			value, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = value.(string)
+		case isIdentifierStart(r):
+			key = p.parseIdentifier()
+		default:
+			return nil, p.errorf("expected object key, found %q", r)
+		}
+
+		p.skipSpace()
+		if p.peek() != ':' {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		p.next()
+		p.skipSpace()
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+		default:
+			return nil, p.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+func (p *parser) parseArray() (interface{}, error) {
+	p.next()
+	array := []interface{}{}
+
+	for {
+		p.skipSpace()
+		if p.peek() == ']' {
+			p.next()
+			return array, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *parser) parseString() (interface{}, error) {
+	quote := p.next()
+	var sb strings.Builder
+
+	for {
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated string")
+		}
+
+		r := p.next()
+		switch {
+		case r == quote:
+			return sb.String(), nil
+		case r == '\n' || r == '\r':
+			return nil, p.errorf("unescaped line break in string")
+		case r != '\\':
+			sb.WriteRune(r)
+			continue
+		}
+
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated string")
+		}
+
+		escape := p.next()
+		switch escape {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0':
+			sb.WriteByte(0)
+		case '\n', '\u2028', '\u2029':
+			// Line continuation.
+		case '\r':
+			if p.peek() == '\n' {
+				p.next()
+			}
+		case 'x':
+			value, err := p.parseHex(2)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteRune(rune(value))
+		case 'u':
+			value, err := p.parseHex(4)
+			if err != nil {
+				return nil, err
+			}
+			r := rune(value)
+			if utf16IsHighSurrogate(r) && strings.HasPrefix(p.data[p.pos:], `\u`) {
+				p.pos += 2
+				low, err := p.parseHex(4)
+				if err != nil {
+					return nil, err
+				}
+				r = (r-0xD800)<<10 + (rune(low) - 0xDC00) + 0x10000
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(escape)
+		}
+	}
+}
+
+func (p *parser) parseHex(digits int) (uint64, error) {
+	if p.pos+digits > len(p.data) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	value, err := strconv.ParseUint(p.data[p.pos:p.pos+digits], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.pos += digits
+	return value, nil
+}
+
+func (p *parser) parseNumber() (interface{}, error) {
+	start := p.pos
+	sign := 1.0
+	if r := p.peek(); r == '+' || r == '-' {
+		if r == '-' {
+			sign = -1
+		}
+		p.next()
+	}
+
+	rest := p.data[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, "Infinity"):
+		p.pos += len("Infinity")
+		return math.Inf(int(sign)), nil
+	case strings.HasPrefix(rest, "NaN"):
+		p.pos += len("NaN")
+		return math.NaN(), nil
+	case strings.HasPrefix(rest, "0x") || strings.HasPrefix(rest, "0X"):
+		p.pos += 2
+		digits := p.pos
+		for p.pos < len(p.data) && isHexDigit(p.data[p.pos]) {
+			p.pos++
+		}
+		value, err := strconv.ParseUint(p.data[digits:p.pos], 16, 64)
+		if err != nil {
+			return nil, p.errorf("invalid hexadecimal number %q", p.data[start:p.pos])
+		}
+		return sign * float64(value), nil
+	}
+
+	for p.pos < len(p.data) && strings.IndexByte("0123456789.eE+-", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+
+	text := p.data[start:p.pos]
+	value, err := strconv.ParseFloat(strings.TrimPrefix(text, "+"), 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return value, nil
+}
+
+func (p *parser) parseIdentifier() string {
+	start := p.pos
+	for p.pos < len(p.data) && isIdentifierPart(p.peek()) {
+		p.next()
+	}
+	return p.data[start:p.pos]
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r)
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func utf16IsHighSurrogate(r rune) bool {
+	return r >= 0xD800 && r < 0xDC00
+}
@@ -0,0 +1,114 @@
+package json5
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{name: "plain json", input: `{"a": [1, "two", true, false, null]}`, want: map[string]interface{}{"a": []interface{}{1.0, "two", true, false, nil}}},
+		{name: "unquoted keys", input: `{extends: ["config:recommended"], $schema: 1, _private: 2}`, want: map[string]interface{}{"extends": []interface{}{"config:recommended"}, "$schema": 1.0, "_private": 2.0}},
+		{name: "single quoted strings", input: `{'key': 'it\'s "quoted"'}`, want: map[string]interface{}{"key": `it's "quoted"`}},
+		{name: "trailing comma in object", input: `{a: 1, b: 2,}`, want: map[string]interface{}{"a": 1.0, "b": 2.0}},
+		{name: "trailing comma in array", input: `[1, 2,]`, want: []interface{}{1.0, 2.0}},
+		{name: "nested trailing commas", input: `{a: [{b: 1,},],}`, want: map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": 1.0}}}},
+		{name: "empty containers", input: `{a: {}, b: []}`, want: map[string]interface{}{"a": map[string]interface{}{}, "b": []interface{}{}}},
+		{name: "hexadecimal", input: `[0x1F, 0XFF, -0xa, +0x10]`, want: []interface{}{31.0, 255.0, -10.0, 16.0}},
+		{name: "leading and trailing decimal point", input: `[.5, 5., -.25]`, want: []interface{}{0.5, 5.0, -0.25}},
+		{name: "explicit plus sign", input: `[+1, +1.5e2]`, want: []interface{}{1.0, 150.0}},
+		{name: "exponent", input: `[1e3, 2E-2]`, want: []interface{}{1000.0, 0.02}},
+		{name: "infinity", input: `[Infinity, +Infinity, -Infinity]`, want: []interface{}{math.Inf(1), math.Inf(1), math.Inf(-1)}},
+		{name: "line comment", input: "// leading\n{a: 1, // trailing\n}", want: map[string]interface{}{"a": 1.0}},
+		{name: "block comment", input: `/* leading */ {a: /* inline */ 1}`, want: map[string]interface{}{"a": 1.0}},
+		{name: "line comment at eof", input: "{a: 1} // done", want: map[string]interface{}{"a": 1.0}},
+		{name: "block comment at eof", input: "{a: 1} /* done */", want: map[string]interface{}{"a": 1.0}},
+		{name: "byte order mark", input: "\uFEFF{a: 1}", want: map[string]interface{}{"a": 1.0}},
+		{name: "escapes", input: `"\b\f\n\r\t\v\0\/\\"`, want: "\b\f\n\r\t\v\x00/\\"},
+		{name: "hex escape", input: `"\x41\x7e"`, want: "A~"},
+		{name: "unicode escape", input: `"\u00e9\u2713"`, want: "é✓"},
+		{name: "surrogate pair", input: `"\uD83D\uDE00"`, want: "\U0001F600"},
+		{name: "raw non-ascii", input: `'grüße 😀'`, want: "grüße \U0001F600"},
+		{name: "line continuation", input: "'one \\\ntwo'", want: "one two"},
+		{name: "crlf line continuation", input: "'one \\\r\ntwo'", want: "one two"},
+		{name: "top level scalar", input: ` 42 `, want: 42.0},
+		{name: "unicode identifier key", input: `{ключ: 1}`, want: map[string]interface{}{"ключ": 1.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNaN(t *testing.T) {
+	for _, input := range []string{"NaN", "+NaN", "-NaN"} {
+		got, err := Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", input, err)
+		}
+		if f, ok := got.(float64); !ok || !math.IsNaN(f) {
+			t.Errorf("Parse(%q) = %v, want NaN", input, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		{name: "empty", input: ``, line: 1, column: 1},
+		{name: "only comment", input: `// nothing`, line: 1, column: 11},
+		{name: "unterminated block comment", input: "{a: 1} /* open", line: 1, column: 8},
+		{name: "unterminated string", input: `{a: 'open}`, line: 1, column: 11},
+		{name: "line break in string", input: "'one\ntwo'", line: 2, column: 1},
+		{name: "missing colon", input: `{a 1}`, line: 1, column: 4},
+		{name: "missing comma", input: "{\n  a: 1\n  b: 2\n}", line: 3, column: 3},
+		{name: "double comma", input: `[1,,2]`, line: 1, column: 4},
+		{name: "unknown identifier", input: `{a: undefined}`, line: 1, column: 14},
+		{name: "invalid number", input: `[1.2.3]`, line: 1, column: 7},
+		{name: "invalid hex", input: `0x`, line: 1, column: 3},
+		{name: "invalid unicode escape", input: `"\u12"`, line: 1, column: 4},
+		{name: "trailing content", input: `{} {}`, line: 1, column: 4},
+		{name: "numeric key", input: `{1: 2}`, line: 1, column: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want a SyntaxError", tt.input, err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+				t.Errorf("Parse(%q) error at %d:%d, want %d:%d (%v)", tt.input, syntaxErr.Line, syntaxErr.Column, tt.line, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	if err := Valid([]byte(`{extends: ['config:recommended'],}`)); err != nil {
+		t.Errorf("Valid returned %v", err)
+	}
+	if err := Valid([]byte(`{`)); err == nil {
+		t.Error("Valid accepted an unterminated object")
+	}
+}
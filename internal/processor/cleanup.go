@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type CleanupOptions struct {
+	Paths      []string
+	Delay      time.Duration
+	TokenFile  string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// Cleanup waits for the delay and then scrubs the given files: their content
+// is overwritten with zeros before they are removed.
+//
+// revoke-token reads the token file, so when the token file is one of the
+// paths its token is revoked before the file is scrubbed. Such a cleanup must
+// only run once Renovate has finished; a cleanup that runs while Renovate is
+// still working should list the config files alone.
+func Cleanup(opts CleanupOptions) error {
+	if len(opts.Paths) == 0 {
+		return fmt.Errorf("no files to clean up")
+	}
+
+	if opts.Delay > 0 {
+		log.Printf("Waiting %s before cleaning up", opts.Delay)
+		time.Sleep(opts.Delay)
+	}
+
+	var errs []error
+	for _, path := range opts.Paths {
+		if opts.TokenFile != "" && filepath.Clean(path) == filepath.Clean(opts.TokenFile) {
+			err := RevokeToken(opts.Endpoint, path, opts.HTTPClient)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("not cleaning up '%s': %v", path, err))
+				continue
+			}
+		}
+
+		err := scrubFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Skipping '%s': file does not exist", path)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error cleaning up '%s': %v", path, err))
+			continue
+		}
+		log.Printf("Removed '%s'", path)
+	}
+
+	return errors.Join(errs...)
+}
+
+func scrubFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	_, err = file.Write(make([]byte, info.Size()))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
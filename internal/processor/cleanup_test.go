@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.js")
+	token := filepath.Join(dir, "token")
+	for _, path := range []string{config, token} {
+		if err := os.WriteFile(path, []byte("ghs_secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var revoked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/api/v3/installation/token" {
+			http.NotFound(w, r)
+			return
+		}
+		// The token must still be on disk when it is revoked.
+		content, err := os.ReadFile(token)
+		if err != nil || string(content) != "ghs_secret" {
+			t.Errorf("token file read during revocation = %q, %v", content, err)
+		}
+		revoked = append(revoked, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := Cleanup(CleanupOptions{
+		Paths:     []string{config, filepath.Join(dir, "missing"), dir + "/./token"},
+		TokenFile: token,
+		Endpoint:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revoked) != 1 || !strings.HasSuffix(revoked[0], "ghs_secret") {
+		t.Errorf("revocations = %q, want one with the file token", revoked)
+	}
+	for _, path := range []string{config, token} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("'%s' still exists: %v", path, err)
+		}
+	}
+}
+
+func TestCleanupKeepsTokenWhenRevokeFails(t *testing.T) {
+	token := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(token, []byte("ghs_secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := Cleanup(CleanupOptions{Paths: []string{token}, TokenFile: token, Endpoint: server.URL})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := os.Stat(token); err != nil {
+		t.Errorf("token file was removed although it was not revoked: %v", err)
+	}
+}
+
+func TestCleanupWithoutPaths(t *testing.T) {
+	if err := Cleanup(CleanupOptions{}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,359 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/coding-ia/renovate-controller/internal/githubtest"
+	"github.com/coding-ia/renovate-controller/internal/httpclient"
+	"github.com/coding-ia/renovate-controller/internal/service"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTransport records the paths requests were sent to, before the
+// server strips the GHES prefix.
+type recordingTransport struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.mu.Unlock()
+	return r.base.RoundTrip(req)
+}
+
+// requireAPIPrefix fails the test unless every request was sent below the
+// GHES /api/v3/ API.
+func (r *recordingTransport) requireAPIPrefix(t *testing.T) {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.paths) == 0 {
+		t.Fatal("no requests were sent")
+	}
+	for _, path := range r.paths {
+		if !strings.HasPrefix(path, githubtest.DefaultPathPrefix+"/") {
+			t.Errorf("request to %s was not sent to the GHES API", path)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) *githubtest.Server {
+	t.Helper()
+
+	var repositories []githubtest.Repository
+	for i := 0; i < 120; i++ {
+		repositories = append(repositories, githubtest.Repository{Owner: "acme", Name: fmt.Sprintf("service-%03d", i)})
+	}
+
+	server, err := githubtest.NewServer(
+		githubtest.Installation{ID: 1, Account: "acme", Repositories: repositories},
+		githubtest.Installation{ID: 2, Account: "octo", Repositories: []githubtest.Repository{
+			{Owner: "octo", Name: "website", OwnerType: "User"},
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// endpointCases returns the server as a bare GHES host trusted through a CA
+// bundle, and as a full URL using the server's own client.
+func endpointCases(t *testing.T, server *githubtest.Server) map[string]func() (string, *recordingTransport) {
+	return map[string]func() (string, *recordingTransport){
+		"bare host with ca bundle": func() (string, *recordingTransport) {
+			caBundle := filepath.Join(t.TempDir(), "ca.pem")
+			if err := os.WriteFile(caBundle, server.CertificatePEM(), 0600); err != nil {
+				t.Fatal(err)
+			}
+			client, err := httpclient.Config{CABundle: caBundle}.Client()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return server.Endpoint(), &recordingTransport{base: client.Transport}
+		},
+		"full url": func() (string, *recordingTransport) {
+			return server.URL, &recordingTransport{base: server.Client().Transport}
+		},
+	}
+}
+
+func TestGenerateEndToEnd(t *testing.T) {
+	server := newTestServer(t)
+
+	for name, endpointCase := range endpointCases(t, server) {
+		t.Run(name, func(t *testing.T) {
+			endpoint, transport := endpointCase()
+			githubConfig := &GitHubConfig{
+				ApplicationID: server.ApplicationID,
+				PrivateKeys:   [][]byte{server.PrivateKeyPEM()},
+				Endpoint:      endpoint,
+				HTTPClient:    &http.Client{Transport: transport},
+			}
+
+			dir := t.TempDir()
+			template := filepath.Join(dir, "config.json.tmpl")
+			err := os.WriteFile(template, []byte(`{
+  "platform": "{{ .Platform }}",
+  "endpoint": "{{ .Endpoint }}",
+  "token": "{{ .InstallationToken }}",
+  "gitAuthor": {{ toJson .GitAuthor }},
+  "repositories": {{ toJson .Repositories }}
+}`), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output := filepath.Join(dir, "config.json")
+			tokenFile := filepath.Join(dir, "token")
+			activeTokens := server.ActiveTokens()
+			err = Generate(githubConfig, GenerateCommandOptions{
+				InstallationID:   1,
+				TargetRepository: "acme/service-042",
+				Template:         "file://" + template,
+				Output:           output,
+				TokenPermissions: service.DefaultTokenPermissions,
+				TokenFile:        tokenFile,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var config struct {
+				Platform     string   `json:"platform"`
+				Endpoint     string   `json:"endpoint"`
+				Token        string   `json:"token"`
+				GitAuthor    string   `json:"gitAuthor"`
+				Repositories []string `json:"repositories"`
+			}
+			if err := json.Unmarshal(content, &config); err != nil {
+				t.Fatalf("invalid config %s: %v", content, err)
+			}
+
+			if want := server.URL + "/api/v3/"; config.Endpoint != want {
+				t.Errorf("endpoint = %q, want %q", config.Endpoint, want)
+			}
+			if config.Platform != "github" {
+				t.Errorf("platform = %q, want github", config.Platform)
+			}
+			if !strings.HasPrefix(config.GitAuthor, "renovate-controller[bot] <41898282+renovate-controller[bot]@") {
+				t.Errorf("gitAuthor = %q, want the app bot user", config.GitAuthor)
+			}
+			// The token is scoped to the target repository.
+			if !reflect.DeepEqual(config.Repositories, []string{"acme/service-042"}) {
+				t.Errorf("repositories = %v, want [acme/service-042]", config.Repositories)
+			}
+
+			token, err := os.ReadFile(tokenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(config.Token, "ghs_") || string(token) != config.Token {
+				t.Errorf("token file = %q, config token = %q", token, config.Token)
+			}
+			if got := server.ActiveTokens(); got != activeTokens+1 {
+				t.Errorf("active tokens = %d, want %d", got, activeTokens+1)
+			}
+			if !slices.Contains(server.Requests(), "POST /app/installations/1/access_tokens") {
+				t.Errorf("no installation token was minted, requests = %v", server.Requests())
+			}
+
+			if err := RevokeToken(endpoint, tokenFile, githubConfig.HTTPClient); err != nil {
+				t.Fatal(err)
+			}
+			if got := server.ActiveTokens(); got != activeTokens {
+				t.Errorf("active tokens after revoke = %d, want %d", got, activeTokens)
+			}
+
+			transport.requireAPIPrefix(t)
+		})
+	}
+}
+
+func TestGenerateEndToEndValidateOnly(t *testing.T) {
+	server := newTestServer(t)
+	githubConfig := &GitHubConfig{
+		ApplicationID: server.ApplicationID,
+		PrivateKeys:   [][]byte{server.PrivateKeyPEM()},
+		Endpoint:      server.URL,
+		HTTPClient:    server.Client(),
+	}
+
+	dir := t.TempDir()
+	template := filepath.Join(dir, "config.json.tmpl")
+	if err := os.WriteFile(template, []byte(`{"token": "{{ .InstallationToken }}"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "config.json")
+	activeTokens := server.ActiveTokens()
+	err := Generate(githubConfig, GenerateCommandOptions{
+		InstallationID: 1,
+		Template:       "file://" + template,
+		Output:         output,
+		ValidateOnly:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("output was written in validate-only mode: %v", err)
+	}
+	if !slices.Contains(server.Requests(), "POST /app/installations/1/access_tokens") {
+		t.Errorf("no installation token was minted, requests = %v", server.Requests())
+	}
+	if got := server.ActiveTokens(); got != activeTokens {
+		t.Errorf("active tokens = %d, want %d", got, activeTokens)
+	}
+}
+
+func TestGenerateEndToEndRejectedKey(t *testing.T) {
+	server := newTestServer(t)
+	other, err := githubtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+
+	githubConfig := &GitHubConfig{
+		ApplicationID: server.ApplicationID,
+		PrivateKeys:   [][]byte{other.PrivateKeyPEM(), server.PrivateKeyPEM()},
+		Endpoint:      server.URL,
+		HTTPClient:    server.Client(),
+	}
+
+	// The first key is rejected, so a rotation in progress still works.
+	client, err := newApplicationClient(githubConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.BaseURL.String() != server.URL+"/api/v3/" {
+		t.Errorf("BaseURL = %s, want %s/api/v3/", client.BaseURL, server.URL)
+	}
+
+	githubConfig.PrivateKeys = githubConfig.PrivateKeys[:1]
+	err = Generate(githubConfig, GenerateCommandOptions{InstallationID: 1, Template: "file:///nonexistent", Output: filepath.Join(t.TempDir(), "config.json")})
+	if err == nil || !strings.Contains(err.Error(), service.ErrNoValidKey.Error()) {
+		t.Errorf("Generate error = %v, want %v", err, service.ErrNoValidKey)
+	}
+}
+
+type fakeECS struct {
+	mu     sync.Mutex
+	inputs []*ecs.RunTaskInput
+}
+
+func (f *fakeECS) RunTask(ctx context.Context, params *ecs.RunTaskInput, optFns ...func(*ecs.Options)) (*ecs.RunTaskOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inputs = append(f.inputs, params)
+	return &ecs.RunTaskOutput{}, nil
+}
+
+// environment returns the init container environment of every launched task.
+func (f *fakeECS) environment() []map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var tasks []map[string]string
+	for _, input := range f.inputs {
+		environment := map[string]string{}
+		for _, pair := range input.Overrides.ContainerOverrides[0].Environment {
+			environment[aws.ToString(pair.Name)] = aws.ToString(pair.Value)
+		}
+		tasks = append(tasks, environment)
+	}
+	return tasks
+}
+
+type fakeEC2 struct{}
+
+func (fakeEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return nil, fmt.Errorf("subnets are configured")
+}
+
+func (fakeEC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return nil, fmt.Errorf("security groups are configured")
+}
+
+func TestRunEndToEnd(t *testing.T) {
+	server := newTestServer(t)
+
+	for name, endpointCase := range endpointCases(t, server) {
+		t.Run(name, func(t *testing.T) {
+			endpoint, transport := endpointCase()
+			githubConfig := &GitHubConfig{
+				ApplicationID: server.ApplicationID,
+				PrivateKeys:   [][]byte{server.PrivateKeyPEM()},
+				Endpoint:      endpoint,
+				HTTPClient:    &http.Client{Transport: transport},
+			}
+
+			ecsClient := &fakeECS{}
+			err := Run(githubConfig, &RunCommandOptions{
+				TaskDefinition: "renovate:1",
+				ClusterName:    "renovate",
+				Subnets:        []string{"subnet-a"},
+				SecurityGroups: []string{"sg-a"},
+				TaskOptions:    TaskCommandOptions{ApplicationID: server.ApplicationID},
+				ECS:            ecsClient,
+				EC2:            fakeEC2{},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every repository of both installations gets a task, across
+			// the pages of the acme installation.
+			var launched []string
+			for _, environment := range ecsClient.environment() {
+				launched = append(launched, environment["GITHUB_INSTALLATION_ID"]+" "+environment["GITHUB_TARGET_REPOSITORY"])
+			}
+			sort.Strings(launched)
+
+			var want []string
+			for _, installation := range server.Installations {
+				for _, repository := range installation.Repositories {
+					want = append(want, fmt.Sprintf("%d %s", installation.ID, repository.FullName()))
+				}
+			}
+			sort.Strings(want)
+
+			if !reflect.DeepEqual(launched, want) {
+				t.Errorf("launched %d tasks, want %d: %v", len(launched), len(want), launched)
+			}
+
+			requests := server.Requests()
+			for _, request := range []string{"GET /app", "GET /app/installations", "POST /app/installations/1/access_tokens", "POST /app/installations/2/access_tokens"} {
+				if !slices.Contains(requests, request) {
+					t.Errorf("missing request %q", request)
+				}
+			}
+
+			transport.requireAPIPrefix(t)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/renovate"
+)
+
+const (
+	FormatConfig = "config"
+	FormatEnv    = "env"
+)
+
+// renderEnv renders the RENOVATE_* environment file. When an env template is
+// configured its output is parsed as KEY=VALUE lines and appended, so it can
+// supply extra variables or override the generated ones.
+func (g GenerateFuncCallback) renderEnv(data TemplateData) ([]byte, error) {
+	opts := g.Command.CommandOptions
+
+	repositories := data.Repositories
+	if repositories == nil {
+		repositories = []string{}
+	}
+	repositoriesJson, err := toJson(repositories)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []renovate.EnvVar{
+		{Name: "RENOVATE_PLATFORM", Value: data.Platform},
+		{Name: "RENOVATE_ENDPOINT", Value: data.Endpoint},
+		{Name: "RENOVATE_REPOSITORIES", Value: repositoriesJson},
+	}
+
+	if data.InstallationToken != "" {
+		vars = append(vars, renovate.EnvVar{Name: "RENOVATE_TOKEN", Value: data.InstallationToken})
+	}
+
+	if data.GitAuthor != "" {
+		vars = append(vars, renovate.EnvVar{Name: "RENOVATE_GIT_AUTHOR", Value: data.GitAuthor})
+	}
+
+	if len(data.HostRules) > 0 {
+		hostRulesJson, err := toJson(data.HostRules)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, renovate.EnvVar{Name: "RENOVATE_HOST_RULES", Value: hostRulesJson})
+	}
+
+	if opts.EnvTemplate != "" {
+		content, err := g.renderTemplate(opts.EnvTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+
+		extras, err := renovate.ParseEnv(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing rendered env template: %v", err)
+		}
+		vars = append(vars, extras...)
+	}
+
+	return renovate.EncodeEnv(vars, opts.EnvQuote)
+}
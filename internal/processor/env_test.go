@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderEnv(t *testing.T) {
+	dir := t.TempDir()
+	configTemplate := filepath.Join(dir, "config.js")
+	envTemplate := filepath.Join(dir, "renovate.env")
+	if err := os.WriteFile(configTemplate, []byte("module.exports = {platform: '{{ .Platform }}'};\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(envTemplate, []byte("# extras\nLOG_LEVEL=debug\nRENOVATE_GIT_AUTHOR='{{ .GitAuthor }} (override)'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := TemplateData{
+		InstallationToken: "ghs_token",
+		Platform:          "github",
+		Endpoint:          "https://api.github.com/",
+		GitAuthor:         "bot <bot@example.com>",
+		Repositories:      []string{"acme/widgets"},
+	}
+
+	tests := []struct {
+		name string
+		opts GenerateCommandOptions
+		want string
+	}{
+		{
+			name: "without env template",
+			opts: GenerateCommandOptions{Template: "file://" + configTemplate},
+			want: `RENOVATE_PLATFORM='github'
+RENOVATE_ENDPOINT='https://api.github.com/'
+RENOVATE_REPOSITORIES='["acme/widgets"]'
+RENOVATE_TOKEN='ghs_token'
+RENOVATE_GIT_AUTHOR='bot <bot@example.com>'
+`,
+		},
+		{
+			name: "with env template",
+			opts: GenerateCommandOptions{Template: "file://" + configTemplate, EnvTemplate: "file://" + envTemplate},
+			want: `RENOVATE_PLATFORM='github'
+RENOVATE_ENDPOINT='https://api.github.com/'
+RENOVATE_REPOSITORIES='["acme/widgets"]'
+RENOVATE_TOKEN='ghs_token'
+RENOVATE_GIT_AUTHOR='bot <bot@example.com>'
+LOG_LEVEL='debug'
+RENOVATE_GIT_AUTHOR='bot <bot@example.com> (override)'
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: tt.opts}}
+			got, err := g.renderEnv(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("renderEnv =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEnvTemplateErrors(t *testing.T) {
+	envTemplate := filepath.Join(t.TempDir(), "renovate.env")
+	if err := os.WriteFile(envTemplate, []byte("module.exports = {};\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: GenerateCommandOptions{EnvTemplate: "file://" + envTemplate}}}
+	_, err := g.renderEnv(TemplateData{})
+	if err == nil || !strings.Contains(err.Error(), "error parsing rendered env template") {
+		t.Errorf("renderEnv error = %v, want a parse error", err)
+	}
+}
@@ -1,18 +1,24 @@
 package processor
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/awsclient"
+	"github.com/coding-ia/renovate-controller/internal/hostrules"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
 	"github.com/coding-ia/renovate-controller/internal/service"
 	"github.com/coding-ia/renovate-controller/internal/store"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v63/github"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"text/template"
 )
 
 type GenerateTaskFunc interface {
-	GenerateConfig(repos []string, installationToken string, endpoint string)
+	GenerateConfig(installation service.InstallationContext) error
 }
 
 type GenerateTask interface {
@@ -22,28 +28,106 @@ type GenerateTask interface {
 type GenerateCommand struct {
 	CommandOptions GenerateCommandOptions
 	GitHubClient   *github.Client
+	HTTPClient     *http.Client
 }
 
 type GenerateCommandOptions struct {
 	InstallationID   int64
 	TargetRepository string
+	Mode             string
+	Template         string
+	Base             string
+	Overlays         []string
 	S3Bucket         string
 	S3ConfigKey      string
 	Output           string
+	Format           string
+	EnvOutput        string
+	EnvTemplate      string
+	EnvQuote         string
+	Renders          []RenderTarget
+	FileMode         os.FileMode
+	SecretFileMode   os.FileMode
+	ValidateOnly     bool
+	TokenPermissions string
+	TokenHandoff     string
+	TokenFile        string
+	HostRules        []hostrules.Config
+	GitHubCom        *GitHubComConfig
+	AWS              *awsclient.Clients
+	Secrets          secrets.Providers
+}
+
+func (o GenerateCommandOptions) TemplateURI() string {
+	if o.Template != "" {
+		return o.Template
+	}
+	return fmt.Sprintf("s3://%s/%s", o.S3Bucket, o.S3ConfigKey)
+}
+
+type RenderTarget struct {
+	Template string
+	Output   string
+}
+
+// ParseRenderTarget parses a "<template-uri>=<output>" pair.
+func ParseRenderTarget(value string) (RenderTarget, error) {
+	index := strings.LastIndex(value, "=")
+	if index <= 0 || index == len(value)-1 {
+		return RenderTarget{}, fmt.Errorf("invalid render target '%s', expected <template>=<output>", value)
+	}
+	return RenderTarget{Template: value[:index], Output: value[index+1:]}, nil
+}
+
+type renderedOutput struct {
+	Path    string
+	Content []byte
+	Env     bool
+	Secret  bool
 }
 
 type GenerateFuncCallback struct {
 	Command GenerateCommand
 }
 
+// hostRuleClients returns the clients host rule credentials are read with.
+func (g GenerateCommand) hostRuleClients() hostrules.Clients {
+	clients := hostrules.Clients{Secrets: g.CommandOptions.Secrets}
+	if g.CommandOptions.AWS != nil {
+		clients.ECR = g.CommandOptions.AWS.ECR
+		clients.CodeArtifact = g.CommandOptions.AWS.CodeArtifact
+		clients.SSM = g.CommandOptions.AWS.SSM
+	}
+	return clients
+}
+
+// sources returns the clients templates are loaded with.
+func (g GenerateCommand) sources() store.Clients {
+	clients := store.Clients{GitHub: g.GitHubClient, HTTP: g.HTTPClient}
+	if g.CommandOptions.AWS != nil {
+		clients.S3 = g.CommandOptions.AWS.S3
+		clients.SSM = g.CommandOptions.AWS.SSM
+	}
+	return clients
+}
+
 func (g GenerateCommand) GenerateConfig() error {
 	var generateTask GenerateTaskFunc
 	generateTask = &GenerateFuncCallback{
 		Command: g,
 	}
 
+	var tokenOptions *github.InstallationTokenOptions
+	if g.CommandOptions.TargetRepository != "" {
+		permissions, err := service.ParseInstallationPermissions(g.CommandOptions.TokenPermissions)
+		if err != nil {
+			return err
+		}
+		tokenOptions = service.ScopedTokenOptions(g.CommandOptions.TargetRepository, permissions)
+	}
+
 	svc := service.NewRenovateGitHubApplicationService(g.GitHubClient)
-	err := svc.ProcessInstallationRepository(g.CommandOptions.InstallationID, generateTask.GenerateConfig)
+	err := svc.ProcessInstallationRepository(g.CommandOptions.InstallationID, tokenOptions, generateTask.GenerateConfig)
 	if err != nil {
 		return fmt.Errorf("error while processing repositoriest: %v", err)
 	}
@@ -52,17 +136,7 @@ func (g GenerateCommand) GenerateConfig() error {
 }
 
 func Generate(githubConfig *GitHubConfig, options GenerateCommandOptions) error {
-	parsedKey, err := jwt.ParseRSAPrivateKeyFromPEM(githubConfig.PrivateKey)
-	if err != nil {
-		return err
-	}
-
-	tokenString, err := service.GenerateJWT(githubConfig.ApplicationID, parsedKey)
-	if err != nil {
-		return fmt.Errorf("error generating JWT: %v", err)
-	}
-
-	client, err := service.CreateClient(tokenString, githubConfig.Endpoint)
+	client, err := newApplicationClient(githubConfig)
 	if err != nil {
 		return fmt.Errorf("error creating github client: %v", err)
 	}
@@ -71,6 +145,7 @@ func Generate(githubConfig *GitHubConfig, options GenerateCommandOptions) error
 	renovateTask = &GenerateCommand{
 		CommandOptions: options,
 		GitHubClient:   client,
+		HTTPClient:     githubConfig.HTTPClient,
 	}
 
 	err = renovateTask.GenerateConfig()
@@ -82,48 +157,212 @@ func Generate(githubConfig *GitHubConfig, options GenerateCommandOptions) error
 }
 
 type TemplateData struct {
+	InstallationID    int64
 	InstallationToken string
+	Platform          string
 	Endpoint          string
+	IsEnterprise      bool
+	GitAuthor         string
+	Bot               *service.BotIdentity
 	Repository        string
 	Repositories      []string
+	RepositoryInfo    *service.RepositoryMetadata
+	RepositoriesInfo  []service.RepositoryMetadata
+	HostRules         []hostrules.HostRule
+	GitHubComToken    string
+	TokenFile         string
 }
 
-func (g GenerateFuncCallback) GenerateConfig(repos []string, installationToken string, endpoint string) {
-	config, err := store.GetS3Object(g.Command.CommandOptions.S3Bucket, g.Command.CommandOptions.S3ConfigKey)
+func (g GenerateFuncCallback) GenerateConfig(installation service.InstallationContext) error {
+	repos := installation.Repositories
+
+	var repoNames []string
+	var repoInfo *service.RepositoryMetadata
+	for i, repo := range repos {
+		repoNames = append(repoNames, repo.FullName)
+		if strings.EqualFold(repo.FullName, g.Command.CommandOptions.TargetRepository) {
+			repoInfo = &repos[i]
+		}
+	}
+
+	if repoInfo != nil && installation.Client != nil {
+		service.LoadCustomProperties(installation.Client, repoInfo)
+	}
+
+	hostRules, err := hostrules.Resolve(context.Background(), g.Command.CommandOptions.HostRules, g.Command.hostRuleClients())
 	if err != nil {
-		log.Printf("error getting SSM parameter: %v", err)
-		return
+		return err
 	}
-	_ = config
 
-	tmpl, err := template.New("config").Parse(config)
+	gitHubComToken, err := resolveGitHubComToken(g.Command.CommandOptions.GitHubCom)
 	if err != nil {
-		log.Printf("Failed to parse template: %v", err)
-		return
+		return err
+	}
+	if gitHubComToken != "" {
+		hostRules = append(hostRules, gitHubComHostRule(gitHubComToken))
 	}
 
 	data := TemplateData{
-		InstallationToken: installationToken,
-		Endpoint:          endpoint,
-		Repositories:      repos,
+		InstallationID:    g.Command.CommandOptions.InstallationID,
+		InstallationToken: installation.Token,
+		Platform:          installation.Platform,
+		Endpoint:          installation.Endpoint,
+		IsEnterprise:      installation.IsEnterprise,
+		GitAuthor:         installation.Bot.GitAuthor(),
+		Bot:               installation.Bot,
+		Repositories:      repoNames,
 		Repository:        g.Command.CommandOptions.TargetRepository,
+		RepositoryInfo:    repoInfo,
+		RepositoriesInfo:  repos,
+		HostRules:         hostRules,
+		GitHubComToken:    gitHubComToken,
 	}
 
 	log.Printf("Template 'Endpoint' = '%s'", data.Endpoint)
+	log.Printf("Template 'IsEnterprise' = '%t'", data.IsEnterprise)
+	log.Printf("Template 'GitAuthor' = '%s'", data.GitAuthor)
 	log.Printf("Template 'Repository' = '%s'", data.Repository)
 
-	file, err := os.Create(g.Command.CommandOptions.Output)
+	opts := g.Command.CommandOptions
+
+	cfgData, err := configData(data, opts)
+	if err != nil {
+		return err
+	}
+	envData := cfgData
+	if opts.TokenHandoff == TokenHandoffEnv {
+		envData = data
+	}
+
+	var content []byte
+	switch {
+	case opts.Format == FormatEnv && opts.Mode == ModeStructured:
+		err = fmt.Errorf("structured mode cannot be combined with the env format")
+	case opts.Format == FormatEnv:
+		content, err = g.renderEnv(envData)
+	case opts.Format != "" && opts.Format != FormatConfig:
+		err = fmt.Errorf("unknown format '%s'", opts.Format)
+	case opts.Mode == "" || opts.Mode == ModeTemplate:
+		content, err = g.renderConfigTemplate(opts.TemplateURI(), cfgData)
+	case opts.Mode == ModeStructured:
+		content, err = g.renderStructured(cfgData)
+	default:
+		err = fmt.Errorf("unknown mode '%s'", opts.Mode)
+	}
 	if err != nil {
-		log.Printf("Failed to create file: %v", err)
-		return
+		return err
+	}
+
+	// Env outputs carry RENOVATE_TOKEN unless the token is handed off in a
+	// file, so they are written like the token file.
+	envSecret := envData.InstallationToken != ""
+	outputs := []renderedOutput{{Path: opts.Output, Content: content, Env: opts.Format == FormatEnv, Secret: opts.Format == FormatEnv && envSecret}}
+	if opts.EnvOutput != "" && opts.Format != FormatEnv {
+		content, err := g.renderEnv(envData)
+		if err != nil {
+			return fmt.Errorf("error rendering '%s': %v", opts.EnvOutput, err)
+		}
+		outputs = append(outputs, renderedOutput{Path: opts.EnvOutput, Content: content, Env: true, Secret: envSecret})
+	}
+	for _, target := range opts.Renders {
+		content, err := g.renderTemplate(target.Template, cfgData)
+		if err != nil {
+			return fmt.Errorf("error rendering '%s': %v", target.Output, err)
+		}
+		outputs = append(outputs, renderedOutput{Path: target.Output, Content: content})
+	}
+	if tokenFile := cfgData.TokenFile; tokenFile != "" || opts.TokenFile != "" {
+		// An explicit token file is also written for the other handoffs so that
+		// revoke-token can pick the token up from the shared volume.
+		if tokenFile == "" {
+			tokenFile = opts.TokenFile
+		}
+		outputs = append(outputs, renderedOutput{Path: tokenFile, Content: []byte(data.InstallationToken), Secret: true})
+	}
+
+	// Only the main output is a Renovate config; --render targets can be any
+	// file Renovate needs next to it.
+	if main := outputs[0]; !main.Env {
+		err = validateConfig(configName(main.Path), main.Content)
+		if err != nil {
+			return fmt.Errorf("invalid renovate config '%s': %v", main.Path, err)
+		}
 	}
-	defer file.Close()
 
-	err = tmpl.Execute(file, data)
+	if opts.ValidateOnly {
+		log.Printf("Template successfully validated for '%s'", opts.Output)
+		// The token is never handed to Renovate, so it is revoked instead of
+		// staying valid until it expires.
+		if installation.Client != nil {
+			return revokeInstallationToken(installation.Client)
+		}
+		return nil
+	}
+
+	for _, output := range outputs {
+		fileMode := opts.FileMode
+		if output.Secret {
+			fileMode = opts.SecretFileMode
+		}
+		err = writeOutput(output.Path, output.Content, fileMode)
+		if err != nil {
+			return err
+		}
+		if output.Path != stdoutPath {
+			log.Printf("Template successfully created at '%s'", output.Path)
+		}
+	}
+
+	return nil
+}
+
+func (g GenerateFuncCallback) renderTemplate(uri string, data TemplateData) ([]byte, error) {
+	tmpl, err := g.loadTemplate(uri)
+	if err != nil {
+		return nil, err
+	}
+	return executeTemplate(tmpl, data)
+}
+
+// renderConfigTemplate renders the main config. With the file token handoff
+// the token is left out of the template data, so the template has to read it
+// from .TokenFile or Renovate would start without credentials.
+func (g GenerateFuncCallback) renderConfigTemplate(uri string, data TemplateData) ([]byte, error) {
+	tmpl, err := g.loadTemplate(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.TokenFile != "" && !referencesField(tmpl, "TokenFile") {
+		return nil, fmt.Errorf("file token handoff requires the template to read the token from .TokenFile")
+	}
+	return executeTemplate(tmpl, data)
+}
+
+func (g GenerateFuncCallback) loadTemplate(uri string) (*template.Template, error) {
+	source, err := store.NewTemplateSource(uri, g.Command.sources())
+	if err != nil {
+		return nil, fmt.Errorf("error resolving template source: %v", err)
+	}
+
+	config, err := source.Load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error loading template: %v", err)
+	}
+
+	tmpl, err := newConfigTemplate("config", config, g.Command.CommandOptions.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	return tmpl, nil
+}
+
+func executeTemplate(tmpl *template.Template, data TemplateData) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, data)
 	if err != nil {
-		log.Printf("Failed to execute template: %v", err)
-		return
+		return nil, fmt.Errorf("failed to execute template: %v", err)
 	}
 
-	log.Printf("Template successfully created at '%s'", g.Command.CommandOptions.Output)
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/hostrules"
+	"github.com/coding-ia/renovate-controller/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-github/v63/github"
+	"net/http"
+)
+
+// GitHubComConfig configures the github.com credentials handed to Renovate
+// for changelog and release-notes lookups when running against GHES. Either
+// Token (a PAT) or an application on github.com can be used.
+type GitHubComConfig struct {
+	Token          string
+	ApplicationID  string
+	PrivateKey     []byte
+	InstallationID int64
+	HTTPClient     *http.Client
+}
+
+func (c *GitHubComConfig) enabled() bool {
+	return c != nil && (c.Token != "" || c.ApplicationID != "")
+}
+
+func resolveGitHubComToken(cfg *GitHubComConfig) (string, error) {
+	if !cfg.enabled() {
+		return "", nil
+	}
+	if cfg.Token != "" {
+		return cfg.Token, nil
+	}
+
+	parsedKey, err := jwt.ParseRSAPrivateKeyFromPEM(cfg.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("error parsing github.com private key: %v", err)
+	}
+
+	ts := service.NewApplicationTokenSourceWithSigner(cfg.ApplicationID, service.RSASigner{PrivateKey: parsedKey})
+	client, err := service.CreateClientWithTokenSource(ts, "", cfg.HTTPClient)
+	if err != nil {
+		return "", err
+	}
+
+	installationID := cfg.InstallationID
+	if installationID == 0 {
+		installations, _, err := client.Apps.ListInstallations(context.Background(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return "", fmt.Errorf("error listing github.com installations: %v", err)
+		}
+		if len(installations) == 0 {
+			return "", fmt.Errorf("github.com application %s has no installations", cfg.ApplicationID)
+		}
+		installationID = installations[0].GetID()
+	}
+
+	tokenOptions := &github.InstallationTokenOptions{
+		Permissions: &github.InstallationPermissions{
+			Contents: github.String("read"),
+			Metadata: github.String("read"),
+		},
+	}
+	token, err := service.NewInstallationTokenSource(client, installationID, tokenOptions).Token()
+	if err != nil {
+		return "", fmt.Errorf("error creating github.com installation token: %v", err)
+	}
+
+	return token.AccessToken, nil
+}
+
+func gitHubComHostRule(token string) hostrules.HostRule {
+	return hostrules.HostRule{
+		MatchHost: "github.com",
+		HostType:  "github",
+		Token:     token,
+	}
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/renovate"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	stdoutPath      = "-"
+	DefaultFileMode = os.FileMode(0600)
+)
+
+// configName returns the name used to pick the config format of an output.
+// Output written to stdout is treated as config.js.
+func configName(path string) string {
+	if path == stdoutPath {
+		return "config.js"
+	}
+	return path
+}
+
+func writeOutput(path string, data []byte, perm os.FileMode) error {
+	if path == stdoutPath {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if perm == 0 {
+		perm = DefaultFileMode
+	}
+	return writeFileAtomic(path, data, perm)
+}
+
+func validateConfig(name string, data []byte) error {
+	if !renovate.IsConfigFormat(name) {
+		return nil
+	}
+
+	config, err := renovate.ParseConfig(name, data)
+	if errors.Is(err, renovate.ErrNotStatic) {
+		log.Printf("Skipping schema validation of '%s': %v", name, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	warnings, err := renovate.Validate(config)
+	for _, warning := range warnings {
+		log.Printf("Config warning: %s", warning)
+	}
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never observe a partially written config.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	tmpName := file.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %v", err)
+	}
+
+	return os.Rename(tmpName, path)
+}
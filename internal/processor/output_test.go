@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOutputFileMode(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		perm os.FileMode
+		want os.FileMode
+	}{
+		{name: "default.js", perm: 0, want: 0600},
+		{name: "group.js", perm: 0640, want: 0640},
+		{name: "shared.js", perm: 0644, want: 0644},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := writeOutput(path, []byte("module.exports = {};\n"), tt.perm); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %o, want %o", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "config.js", data: `module.exports = {platform: 'github'};`},
+		{name: "config.js", data: `module.exports = {platform: 'sourceforge'};`, wantErr: true},
+		{name: "config.js", data: `module.exports = {token: process.env.TOKEN};`},
+		{name: "config.json", data: `{"onboarding": "yes"}`, wantErr: true},
+		{name: ".npmrc", data: `//registry.npmjs.org/:_authToken=secret`},
+	}
+
+	for _, tt := range tests {
+		err := validateConfig(tt.name, []byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateConfig(%q, %q) error = %v, wantErr %t", tt.name, tt.data, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/service"
+	"github.com/google/go-github/v63/github"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RevokeToken revokes the installation token stored in tokenFile, so it
+// cannot be used after the Renovate run has finished.
+func RevokeToken(endpoint string, tokenFile string, httpClient *http.Client) error {
+	if tokenFile == "" {
+		return fmt.Errorf("no token file configured")
+	}
+
+	content, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return fmt.Errorf("error reading token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return fmt.Errorf("token file '%s' is empty", tokenFile)
+	}
+
+	client, err := service.CreateClient(token, endpoint, httpClient)
+	if err != nil {
+		return fmt.Errorf("error creating github client: %v", err)
+	}
+
+	return revokeInstallationToken(client)
+}
+
+// revokeInstallationToken revokes the installation token client is
+// authenticated with.
+func revokeInstallationToken(client *github.Client) error {
+	_, err := client.Apps.RevokeInstallationToken(context.Background())
+	if service.IsUnauthorized(err) {
+		log.Printf("Installation token has already expired or been revoked")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error revoking installation token: %v", err)
+	}
+
+	log.Printf("Installation token revoked")
+	return nil
+}
@@ -2,11 +2,13 @@ package processor
 
 import (
 	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/httpclient"
 	internalservice "github.com/coding-ia/renovate-controller/internal/service"
 	"github.com/coding-ia/renovate-controller/service"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v63/github"
 	"log"
+	"net/http"
 	"strconv"
 )
 
@@ -32,12 +34,82 @@ type RunCommandOptions struct {
 	Subnets        []string
 	SecurityGroups []string
 	TaskOptions    TaskCommandOptions
+	HTTPConfig     httpclient.Config
+	TaskCABundle   string
+	TaskClientCert string
+	TaskClientKey  string
+	ECS            service.ECSAPI
+	EC2            service.EC2API
+
+	taskService *service.TaskService
 }
 
+// GitHubConfig holds the app credentials. PrivateKeys are tried in order, so
+// during a key rotation both the new and the old key can be configured.
 type GitHubConfig struct {
 	ApplicationID string
-	PrivateKey    []byte
+	PrivateKeys   [][]byte
+	KMSKeyID      string
+	KMS           internalservice.KMSAPI
 	Endpoint      string
+	HTTPClient    *http.Client
+}
+
+type KeyCheckResult struct {
+	KeyID           string
+	ApplicationSlug string
+	Err             error
+}
+
+func newSigners(githubConfig *GitHubConfig) ([]internalservice.Signer, error) {
+	var signers []internalservice.Signer
+	if githubConfig.KMSKeyID != "" {
+		if githubConfig.KMS == nil {
+			return nil, fmt.Errorf("no KMS client configured for key '%s'", githubConfig.KMSKeyID)
+		}
+		signers = append(signers, internalservice.NewKMSSigner(githubConfig.KMSKeyID, githubConfig.KMS))
+	}
+
+	for i, privateKey := range githubConfig.PrivateKeys {
+		parsedKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing private key %d: %v", i+1, err)
+		}
+		signers = append(signers, internalservice.RSASigner{PrivateKey: parsedKey})
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no private key or KMS key configured")
+	}
+	return signers, nil
+}
+
+func newApplicationClient(githubConfig *GitHubConfig) (*github.Client, error) {
+	signers, err := newSigners(githubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return internalservice.SelectSigner(githubConfig.ApplicationID, githubConfig.Endpoint, signers, githubConfig.HTTPClient)
+}
+
+// CheckKeys verifies every configured key against GitHub.
+func CheckKeys(githubConfig *GitHubConfig) ([]KeyCheckResult, error) {
+	signers, err := newSigners(githubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []KeyCheckResult
+	for _, signer := range signers {
+		_, app, err := internalservice.CheckSigner(githubConfig.ApplicationID, githubConfig.Endpoint, signer, githubConfig.HTTPClient)
+		results = append(results, KeyCheckResult{
+			KeyID:           signer.ID(),
+			ApplicationSlug: app.GetSlug(),
+			Err:             err,
+		})
+	}
+	return results, nil
 }
 
 type RenovateCommand struct {
@@ -50,33 +122,39 @@ func (r RenovateCommand) CreateRenovateTasks() error {
 	renovateTask = r.RunOptions
 
 	svc := internalservice.NewRenovateGitHubApplicationService(r.GitHubClient)
-	err := svc.EnumerateInstallationRepositories(renovateTask.CreateTask)
+	summary, err := svc.EnumerateInstallationRepositories(renovateTask.CreateTask)
 	if err != nil {
 		return fmt.Errorf("error while processing repositoriest: %v", err)
 	}
 
+	log.Printf("Processed %d repositories across %d installations", summary.Repositories, summary.Installations)
+	for _, failure := range summary.Failures {
+		log.Printf("Installation %d (%s) failed: %v", failure.InstallationID, failure.Account, failure.Err)
+	}
+
+	if len(summary.Failures) > 0 {
+		return fmt.Errorf("%d of %d installations failed", len(summary.Failures), summary.Installations)
+	}
+
 	return nil
 }
 
 func Run(githubConfig *GitHubConfig, runConfig *RunCommandOptions) error {
-	parsedKey, err := jwt.ParseRSAPrivateKeyFromPEM(githubConfig.PrivateKey)
+	client, err := newApplicationClient(githubConfig)
 	if err != nil {
-		return err
+		return fmt.Errorf("error creating github client: %v", err)
 	}
 
-	tokenString, err := internalservice.GenerateJWT(githubConfig.ApplicationID, parsedKey)
-	if err != nil {
-		return fmt.Errorf("error generating JWT: %v", err)
+	if runConfig.ECS == nil || runConfig.EC2 == nil {
+		return fmt.Errorf("no ECS and EC2 clients configured")
 	}
 
-	client, err := internalservice.CreateClient(tokenString, githubConfig.Endpoint)
-	if err != nil {
-		return fmt.Errorf("error creating github client: %v", err)
-	}
+	runOptions := *runConfig
+	runOptions.taskService = service.NewRenovateTaskService(runOptions.ecsConfig(), runConfig.ECS, service.NewNetworkCache(runConfig.EC2))
 
 	var renovateTask RenovateTask
 	renovateTask = &RenovateCommand{
-		RunOptions:   runConfig,
+		RunOptions:   &runOptions,
 		GitHubClient: client,
 	}
 
@@ -94,7 +172,22 @@ func (r RunCommandOptions) CreateTask(installation *github.Installation, reposit
 
 	log.Printf("Creating renovate task for %s", repo)
 
-	config := service.ECSConfig{
+	taskConfig := service.RunTaskConfig{
+		ApplicationID:  r.TaskOptions.ApplicationID,
+		Repository:     repo,
+		InstallationID: installationID,
+	}
+	_, err := r.taskService.RunTask(taskConfig)
+	if err != nil {
+		log.Printf("error running task: %v", err)
+		return
+	}
+}
+
+func (r RunCommandOptions) ecsConfig() service.ECSConfig {
+	initEnvironment, containerEnvironment := r.taskEnvironment()
+
+	return service.ECSConfig{
 		Cluster:   r.ClusterName,
 		Task:      r.TaskDefinition,
 		Container: r.ContainerName,
@@ -103,18 +196,42 @@ func (r RunCommandOptions) CreateTask(installation *github.Installation, reposit
 			SecurityGroups: r.SecurityGroups,
 			AssignPublicIP: r.AssignPublicIP,
 		},
+		InitEnvironment:      initEnvironment,
+		ContainerEnvironment: containerEnvironment,
 	}
+}
 
-	svc := service.NewRenovateTaskService(config)
+// taskEnvironment forwards the CA bundle, proxy and client certificate to
+// the launched task, so generate-config and Renovate trust the same CA. Only
+// paths are forwarded: the files are expected at the same paths inside the
+// task unless TaskCABundle, TaskClientCert and TaskClientKey are set.
+func (r RunCommandOptions) taskEnvironment() (map[string]string, map[string]string) {
+	initEnvironment := map[string]string{}
+	containerEnvironment := map[string]string{}
+
+	caBundle := taskPath(r.TaskCABundle, r.HTTPConfig.CABundle)
+	if caBundle != "" {
+		initEnvironment[httpclient.EnvCABundle] = caBundle
+		containerEnvironment["NODE_EXTRA_CA_CERTS"] = caBundle
+	}
 
-	taskConfig := service.RunTaskConfig{
-		ApplicationID:  r.TaskOptions.ApplicationID,
-		Repository:     repo,
-		InstallationID: installationID,
+	clientCert := taskPath(r.TaskClientCert, r.HTTPConfig.ClientCert)
+	if clientCert != "" {
+		initEnvironment[httpclient.EnvClientCert] = clientCert
+		initEnvironment[httpclient.EnvClientKey] = taskPath(r.TaskClientKey, r.HTTPConfig.ClientKey)
 	}
-	_, err := svc.RunTask(taskConfig)
-	if err != nil {
-		log.Printf("error running task: %v", err)
-		return
+
+	for name, value := range httpclient.ProxyEnvironment(r.HTTPConfig.Proxy) {
+		initEnvironment[name] = value
+		containerEnvironment[name] = value
+	}
+
+	return initEnvironment, containerEnvironment
+}
+
+func taskPath(override string, path string) string {
+	if override != "" {
+		return override
 	}
+	return path
 }
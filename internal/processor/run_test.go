@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"github.com/coding-ia/renovate-controller/internal/httpclient"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTaskEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   RunCommandOptions
+		env       map[string]string
+		init      map[string]string
+		container map[string]string
+	}{
+		{
+			name:      "nothing configured",
+			init:      map[string]string{},
+			container: map[string]string{},
+		},
+		{
+			name:      "host ca bundle",
+			options:   RunCommandOptions{HTTPConfig: httpclient.Config{CABundle: "/etc/ssl/corp.pem"}},
+			init:      map[string]string{httpclient.EnvCABundle: "/etc/ssl/corp.pem"},
+			container: map[string]string{"NODE_EXTRA_CA_CERTS": "/etc/ssl/corp.pem"},
+		},
+		{
+			name: "task ca bundle",
+			options: RunCommandOptions{
+				HTTPConfig:   httpclient.Config{CABundle: "/etc/ssl/corp.pem"},
+				TaskCABundle: "/config/ca.pem",
+			},
+			init:      map[string]string{httpclient.EnvCABundle: "/config/ca.pem"},
+			container: map[string]string{"NODE_EXTRA_CA_CERTS": "/config/ca.pem"},
+		},
+		{
+			name: "host client certificate",
+			options: RunCommandOptions{
+				HTTPConfig: httpclient.Config{ClientCert: "/etc/ssl/client.pem", ClientKey: "/etc/ssl/client-key.pem"},
+			},
+			init: map[string]string{
+				httpclient.EnvClientCert: "/etc/ssl/client.pem",
+				httpclient.EnvClientKey:  "/etc/ssl/client-key.pem",
+			},
+			container: map[string]string{},
+		},
+		{
+			name: "task client certificate",
+			options: RunCommandOptions{
+				HTTPConfig:     httpclient.Config{ClientCert: "/etc/ssl/client.pem", ClientKey: "/etc/ssl/client-key.pem"},
+				TaskClientCert: "/config/client.pem",
+				TaskClientKey:  "/config/client-key.pem",
+			},
+			init: map[string]string{
+				httpclient.EnvClientCert: "/config/client.pem",
+				httpclient.EnvClientKey:  "/config/client-key.pem",
+			},
+			container: map[string]string{},
+		},
+		{
+			name: "task client certificate only",
+			options: RunCommandOptions{
+				TaskClientCert: "/config/client.pem",
+				TaskClientKey:  "/config/client-key.pem",
+			},
+			init: map[string]string{
+				httpclient.EnvClientCert: "/config/client.pem",
+				httpclient.EnvClientKey:  "/config/client-key.pem",
+			},
+			container: map[string]string{},
+		},
+		{
+			name:    "explicit proxy",
+			options: RunCommandOptions{HTTPConfig: httpclient.Config{Proxy: "http://proxy:3128"}},
+			env:     map[string]string{"NO_PROXY": "169.254.169.254"},
+			init: map[string]string{
+				"HTTPS_PROXY": "http://proxy:3128",
+				"HTTP_PROXY":  "http://proxy:3128",
+				"NO_PROXY":    "169.254.169.254",
+			},
+			container: map[string]string{
+				"HTTPS_PROXY": "http://proxy:3128",
+				"HTTP_PROXY":  "http://proxy:3128",
+				"NO_PROXY":    "169.254.169.254",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+				t.Setenv(name, tt.env[name])
+				t.Setenv(strings.ToLower(name), "")
+			}
+
+			initEnv, container := tt.options.taskEnvironment()
+			if !reflect.DeepEqual(initEnv, tt.init) {
+				t.Errorf("init environment = %v, want %v", initEnv, tt.init)
+			}
+			if !reflect.DeepEqual(container, tt.container) {
+				t.Errorf("container environment = %v, want %v", container, tt.container)
+			}
+		})
+	}
+}
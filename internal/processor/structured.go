@@ -0,0 +1,186 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/renovate"
+	"github.com/coding-ia/renovate-controller/internal/store"
+	"gopkg.in/yaml.v3"
+	"log"
+	"path"
+	"strings"
+)
+
+const (
+	ModeTemplate   = "template"
+	ModeStructured = "structured"
+)
+
+// ConfigOverlay is one entry of an overlay document. An overlay without an
+// installation or repository selector applies globally; repository is a
+// path.Match glob against "owner/name".
+type ConfigOverlay struct {
+	Installation  int64                  `yaml:"installation"`
+	Installations []int64                `yaml:"installations"`
+	Repository    string                 `yaml:"repository"`
+	Config        map[string]interface{} `yaml:"config"`
+}
+
+type overlayDocument struct {
+	Overlays []ConfigOverlay `yaml:"overlays"`
+}
+
+func (o ConfigOverlay) isGlobal() bool {
+	return o.Installation == 0 && len(o.Installations) == 0 && o.Repository == ""
+}
+
+func (o ConfigOverlay) matchesInstallation(installationID int64) bool {
+	if o.Installation != 0 && o.Installation == installationID {
+		return true
+	}
+	for _, id := range o.Installations {
+		if id == installationID {
+			return true
+		}
+	}
+	return false
+}
+
+func (o ConfigOverlay) matchesRepository(repository string) (bool, error) {
+	if o.Repository == "" || repository == "" {
+		return false, nil
+	}
+	return path.Match(strings.ToLower(o.Repository), strings.ToLower(repository))
+}
+
+func (g GenerateFuncCallback) renderStructured(data TemplateData) ([]byte, error) {
+	opts := g.Command.CommandOptions
+
+	config := map[string]interface{}{}
+	if opts.Base != "" {
+		base, err := loadYAMLDocument(opts.Base, g.Command.sources())
+		if err != nil {
+			return nil, fmt.Errorf("error loading base config: %v", err)
+		}
+		config = base
+	}
+
+	var overlays []ConfigOverlay
+	for _, uri := range opts.Overlays {
+		loaded, err := loadOverlays(uri, g.Command.sources())
+		if err != nil {
+			return nil, fmt.Errorf("error loading overlay '%s': %v", uri, err)
+		}
+		overlays = append(overlays, loaded...)
+	}
+
+	// Apply overlays from least to most specific, keeping their order
+	// within each tier.
+	for _, overlay := range overlays {
+		if overlay.isGlobal() {
+			config = renovate.Merge(config, overlay.Config)
+		}
+	}
+	for _, overlay := range overlays {
+		if overlay.matchesInstallation(data.InstallationID) {
+			config = renovate.Merge(config, overlay.Config)
+		}
+	}
+	for _, overlay := range overlays {
+		matched, err := overlay.matchesRepository(data.Repository)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository pattern '%s': %v", overlay.Repository, err)
+		}
+		if matched {
+			config = renovate.Merge(config, overlay.Config)
+		}
+	}
+
+	repositories := make([]interface{}, 0, len(data.Repositories))
+	for _, repo := range data.Repositories {
+		repositories = append(repositories, repo)
+	}
+
+	if len(data.HostRules) > 0 {
+		rules, err := toGeneric(data.HostRules)
+		if err != nil {
+			return nil, err
+		}
+		config = renovate.Merge(config, map[string]interface{}{"hostRules": rules})
+	}
+
+	if _, found := config["gitAuthor"]; !found && data.GitAuthor != "" {
+		config["gitAuthor"] = data.GitAuthor
+	}
+
+	config["platform"] = data.Platform
+	config["endpoint"] = data.Endpoint
+	config["repositories"] = repositories
+
+	if data.TokenFile != "" {
+		return encodeTokenFileConfig(configName(opts.Output), config, data.TokenFile)
+	}
+	if data.InstallationToken != "" {
+		config["token"] = data.InstallationToken
+	}
+
+	return renovate.Encode(configName(opts.Output), config)
+}
+
+func loadYAMLDocument(uri string, clients store.Clients) (map[string]interface{}, error) {
+	source, err := store.NewTemplateSource(uri, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := source.Load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(content), &document)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func loadOverlays(uri string, clients store.Clients) ([]ConfigOverlay, error) {
+	document, err := loadYAMLDocument(uri, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := document["overlays"]; found {
+		var overlays overlayDocument
+		err = yaml.Unmarshal(content, &overlays)
+		return overlays.Overlays, err
+	}
+
+	if _, found := document["config"]; !found {
+		log.Printf("Overlay '%s' has no 'config' section, treating it as a global config", uri)
+		return []ConfigOverlay{{Config: document}}, nil
+	}
+
+	var overlay ConfigOverlay
+	err = yaml.Unmarshal(content, &overlay)
+	return []ConfigOverlay{overlay}, err
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	err = json.Unmarshal(content, &generic)
+	return generic, err
+}
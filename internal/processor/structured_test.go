@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeStructuredFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + path
+}
+
+func decodeJSONConfig(t *testing.T, content []byte) map[string]interface{} {
+	t.Helper()
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(content, &config); err != nil {
+		t.Fatalf("invalid config %s: %v", content, err)
+	}
+	return config
+}
+
+func TestRenderStructuredOverlayOrder(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStructuredFile(t, dir, "base.yaml", `
+extends: [base]
+timezone: UTC
+labels: [dependencies]
+`)
+	// Overlays are listed from most to least specific to show that the tier,
+	// not the listing order, decides which one wins.
+	overlays := writeStructuredFile(t, dir, "overlays.yaml", `
+overlays:
+  - repository: "acme/*"
+    config:
+      extends: [repository-glob]
+      timezone: Europe/Berlin
+  - repository: "acme/widgets"
+    config:
+      extends: [repository]
+  - installations: [7, 42]
+    config:
+      extends: [installation]
+      timezone: America/New_York
+      labels: [installation]
+  - installation: 99
+    config:
+      extends: [other-installation]
+  - repository: "other/*"
+    config:
+      extends: [other-repository]
+  - config:
+      extends: [global]
+      timezone: Asia/Tokyo
+`)
+	// An overlay document without a config section is a global overlay.
+	fallback := writeStructuredFile(t, dir, "fallback.yaml", `
+extends: [fallback]
+prHourlyLimit: 4
+`)
+
+	g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: GenerateCommandOptions{
+		Base:     base,
+		Overlays: []string{overlays, fallback},
+		Output:   filepath.Join(dir, "config.json"),
+	}}}
+
+	content, err := g.renderStructured(TemplateData{
+		InstallationID:    42,
+		InstallationToken: "ghs_token",
+		Platform:          "github",
+		Endpoint:          "https://api.github.com/",
+		Repository:        "acme/widgets",
+		Repositories:      []string{"acme/widgets"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := decodeJSONConfig(t, content)
+	wantExtends := []interface{}{"base", "global", "fallback", "installation", "repository-glob", "repository"}
+	if !reflect.DeepEqual(config["extends"], wantExtends) {
+		t.Errorf("extends = %v, want %v", config["extends"], wantExtends)
+	}
+	if config["timezone"] != "Europe/Berlin" {
+		t.Errorf("timezone = %v, want the repository overlay to win", config["timezone"])
+	}
+	if !reflect.DeepEqual(config["labels"], []interface{}{"installation"}) {
+		t.Errorf("labels = %v, want them replaced by the installation overlay", config["labels"])
+	}
+	if config["prHourlyLimit"] != float64(4) {
+		t.Errorf("prHourlyLimit = %v, want 4 from the fallback overlay", config["prHourlyLimit"])
+	}
+	if config["token"] != "ghs_token" || config["platform"] != "github" {
+		t.Errorf("token/platform = %v/%v", config["token"], config["platform"])
+	}
+}
+
+func TestLoadOverlays(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []ConfigOverlay
+	}{
+		{
+			name:    "overlay list",
+			content: "overlays:\n  - installation: 1\n    config: {a: 1}\n  - repository: acme/*\n    config: {b: 2}\n",
+			want: []ConfigOverlay{
+				{Installation: 1, Config: map[string]interface{}{"a": 1}},
+				{Repository: "acme/*", Config: map[string]interface{}{"b": 2}},
+			},
+		},
+		{
+			name:    "single overlay",
+			content: "installations: [1, 2]\nconfig: {a: 1}\n",
+			want:    []ConfigOverlay{{Installations: []int64{1, 2}, Config: map[string]interface{}{"a": 1}}},
+		},
+		{
+			name:    "without config section",
+			content: "a: 1\nextends: [x]\n",
+			want:    []ConfigOverlay{{Config: map[string]interface{}{"a": 1, "extends": []interface{}{"x"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri := writeStructuredFile(t, dir, strings.ReplaceAll(tt.name, " ", "-")+".yaml", tt.content)
+			got, err := loadOverlays(uri, GenerateCommand{}.sources())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadOverlays = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderStructuredTokenFile(t *testing.T) {
+	for _, output := range []string{"config.ts", "config.js", "config.cjs"} {
+		t.Run(output, func(t *testing.T) {
+			g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: GenerateCommandOptions{Output: output}}}
+			content, err := g.renderStructured(TemplateData{Platform: "github", TokenFile: "/data/token"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(content), `config.token = require('fs').readFileSync("/data/token", 'utf8').trim();`) {
+				t.Errorf("config does not read the token file:\n%s", content)
+			}
+		})
+	}
+
+	g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: GenerateCommandOptions{Output: "config.json"}}}
+	_, err := g.renderStructured(TemplateData{Platform: "github", TokenFile: "/data/token"})
+	if err == nil || !strings.Contains(err.Error(), "file token handoff requires a .js, .cjs or .ts config") {
+		t.Errorf("renderStructured error = %v, want the token file format error", err)
+	}
+}
@@ -0,0 +1,207 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// newConfigTemplate parses a config template. providers back the secret
+// function.
+func newConfigTemplate(name string, text string, providers secrets.Providers) (*template.Template, error) {
+	return template.New(name).
+		Option("missingkey=error").
+		Funcs(templateFuncs(providers)).
+		Parse(text)
+}
+
+func templateFuncs(providers secrets.Providers) template.FuncMap {
+	return template.FuncMap{
+		"toJson":          toJson,
+		"toPrettyJson":    toPrettyJson,
+		"toJs":            toJs,
+		"quote":           quote,
+		"join":            join,
+		"env":             os.Getenv,
+		"default":         defaultValue,
+		"required":        required,
+		"b64enc":          b64enc,
+		"b64dec":          b64dec,
+		"regexMatch":      regexMatch,
+		"regexFind":       regexFind,
+		"regexFindAll":    regexFindAll,
+		"regexReplaceAll": regexReplaceAll,
+		"secret":          secretFunc(providers),
+	}
+}
+
+func marshalJson(v interface{}, indent string) (string, error) {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func toJson(v interface{}) (string, error) {
+	return marshalJson(v, "")
+}
+
+func toPrettyJson(v interface{}) (string, error) {
+	return marshalJson(v, "  ")
+}
+
+// toJs renders a value as a JavaScript literal. JSON is valid JavaScript, the
+// only extra care needed is for sequences that end a script block.
+func toJs(v interface{}) (string, error) {
+	out, err := marshalJson(v, "")
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(out, "</", `<\/`), nil
+}
+
+func quote(v interface{}) (string, error) {
+	return marshalJson(toString(v), "")
+}
+
+func join(sep string, v interface{}) (string, error) {
+	list, err := toStringSlice(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(list, sep), nil
+}
+
+func defaultValue(def interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || isEmpty(v[0]) {
+		return def
+	}
+	return v[0]
+}
+
+func required(message string, v interface{}) (interface{}, error) {
+	if isEmpty(v) {
+		return nil, fmt.Errorf("%s", message)
+	}
+	return v, nil
+}
+
+func b64enc(v interface{}) string {
+	return base64.StdEncoding.EncodeToString([]byte(toString(v)))
+}
+
+func b64dec(v interface{}) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(toString(v))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func regexMatch(pattern string, v interface{}) (bool, error) {
+	return regexp.MatchString(pattern, toString(v))
+}
+
+func regexFind(pattern string, v interface{}) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.FindString(toString(v)), nil
+}
+
+func regexFindAll(pattern string, v interface{}) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.FindAllString(toString(v), -1), nil
+}
+
+func regexReplaceAll(pattern string, v interface{}, replacement string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(toString(v), replacement), nil
+}
+
+func secretFunc(providers secrets.Providers) func(string) (string, error) {
+	return func(secretID string) (string, error) {
+		value, err := providers.GetSecret(secretID)
+		if err != nil {
+			return "", fmt.Errorf("error retrieving secret %q: %v", secretID, err)
+		}
+		return value, nil
+	}
+}
+
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case fmt.Stringer:
+		return value.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func toStringSlice(v interface{}) ([]string, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return value, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot join %T, expected a list", v)
+	}
+
+	list := make([]string, rv.Len())
+	for i := range list {
+		list[i] = toString(rv.Index(i).Interface())
+	}
+	return list, nil
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"bytes"
+	"flag"
+	"github.com/coding-ia/renovate-controller/internal/secrets"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func templateTestData() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":      "acme/widgets",
+		"Branch":    "develop",
+		"Version":   "v1.22.3",
+		"Html":      "<a href=\"x\">&</a>",
+		"Script":    "</script><script>alert(1)</script>",
+		"Multiline": "line one\nline \"two\"",
+		"Number":    42,
+		"Zero":      0,
+		"Missing":   nil,
+		"List":      []string{"dependencies", "renovate"},
+		"Numbers":   []int{1, 2, 3},
+		"Object": map[string]interface{}{
+			"enabled":  true,
+			"schedule": []string{"before 6am"},
+			"labels":   map[string]string{"type": "deps"},
+		},
+	}
+}
+
+// TestTemplateFuncsGolden renders testdata/template_funcs/<helper>.tmpl and
+// compares the result with <helper>.golden. Run with -update to rewrite them.
+func TestTemplateFuncsGolden(t *testing.T) {
+	t.Setenv("TEMPLATE_FUNCS_TEST", "from-env")
+	t.Setenv("TEMPLATE_FUNCS_SECRET", "s3cr3t")
+	t.Setenv("TEMPLATE_FUNCS_JSON", `{"username":"bot","password":"hunter2"}`)
+
+	templates, err := filepath.Glob(filepath.Join("testdata", "template_funcs", "*.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := map[string]bool{}
+	for _, path := range templates {
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		covered[name] = true
+
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tmpl, err := newConfigTemplate(name, string(text), secrets.NewProviders(nil, nil, nil, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			if err := tmpl.Execute(buf, templateTestData()); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(path, ".tmpl") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("output mismatch\n got: %q\nwant: %q", got, want)
+			}
+		})
+	}
+
+	for name := range templateFuncs(nil) {
+		if !covered[name] {
+			t.Errorf("template helper %q has no golden test", name)
+		}
+	}
+}
+
+func TestTemplateFuncsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{name: "required", template: `{{ required "name is required" .Missing }}`, err: "name is required"},
+		{name: "required empty string", template: `{{ required "branch is required" "" }}`, err: "branch is required"},
+		{name: "join scalar", template: `{{ join "," .Name }}`, err: "cannot join string"},
+		{name: "b64dec invalid", template: `{{ b64dec "not base64!" }}`, err: "illegal base64"},
+		{name: "regex invalid", template: `{{ regexFind "(" .Name }}`, err: "missing closing )"},
+		{name: "secret missing", template: `{{ secret "env://TEMPLATE_FUNCS_UNSET" }}`, err: "TEMPLATE_FUNCS_UNSET"},
+		{name: "missing key", template: `{{ .Unknown }}`, err: "map has no entry for key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := newConfigTemplate(tt.name, tt.template, secrets.NewProviders(nil, nil, nil, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tmpl.Execute(new(bytes.Buffer), templateTestData())
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+const (
+	TokenHandoffInline = "inline"
+	TokenHandoffEnv    = "env"
+	TokenHandoffFile   = "file"
+)
+
+// TokenFilePath returns where the file token handoff writes the installation
+// token, next to the config output unless configured.
+func (o GenerateCommandOptions) TokenFilePath() string {
+	if o.TokenFile != "" {
+		return o.TokenFile
+	}
+	dir := "."
+	if o.Output != stdoutPath {
+		dir = filepath.Dir(o.Output)
+	}
+	return filepath.Join(dir, "token")
+}
+
+// configData returns the template data used for config outputs. Unless the
+// token is handed off inline it is left out of the rendered config.
+func configData(data TemplateData, opts GenerateCommandOptions) (TemplateData, error) {
+	switch opts.TokenHandoff {
+	case "", TokenHandoffInline:
+		return data, nil
+	case TokenHandoffEnv:
+		if opts.Format != FormatEnv && opts.EnvOutput == "" {
+			return data, fmt.Errorf("env token handoff requires an env output")
+		}
+		data.InstallationToken = ""
+		return data, nil
+	case TokenHandoffFile:
+		if opts.Format == FormatEnv {
+			return data, fmt.Errorf("file token handoff cannot be combined with the env format")
+		}
+		data.InstallationToken = ""
+		data.TokenFile = opts.TokenFilePath()
+		return data, nil
+	}
+	return data, fmt.Errorf("unknown token handoff '%s'", opts.TokenHandoff)
+}
+
+// encodeTokenFileConfig renders a CommonJS config which reads the token from
+// tokenFile when Renovate loads it.
+func encodeTokenFileConfig(name string, config map[string]interface{}, tokenFile string) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".js" && ext != ".cjs" && ext != ".ts" {
+		return nil, fmt.Errorf("file token handoff requires a .js, .cjs or .ts config, got '%s'", name)
+	}
+
+	body, err := marshalJson(config, "  ")
+	if err != nil {
+		return nil, err
+	}
+	path, err := toJs(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("const config = %s;\nconfig.token = require('fs').readFileSync(%s, 'utf8').trim();\nmodule.exports = config;\n", body, path)
+	return []byte(content), nil
+}
+
+// referencesField reports whether tmpl or one of its associated templates
+// uses the data field name, as .Name or $.Name.
+func referencesField(tmpl *template.Template, name string) bool {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && nodeReferencesField(t.Tree.Root, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeReferencesField(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if nodeReferencesField(child, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeReferencesField(n.Pipe, name)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if nodeReferencesField(cmd, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeReferencesField(arg, name) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == name
+	case *parse.VariableNode:
+		return len(n.Ident) > 1 && n.Ident[0] == "$" && n.Ident[1] == name
+	case *parse.ChainNode:
+		return nodeReferencesField(n.Node, name)
+	case *parse.IfNode:
+		return nodeReferencesField(&n.BranchNode, name)
+	case *parse.RangeNode:
+		return nodeReferencesField(&n.BranchNode, name)
+	case *parse.WithNode:
+		return nodeReferencesField(&n.BranchNode, name)
+	case *parse.BranchNode:
+		return nodeReferencesField(n.Pipe, name) ||
+			nodeReferencesField(n.List, name) ||
+			nodeReferencesField(n.ElseList, name)
+	case *parse.TemplateNode:
+		return nodeReferencesField(n.Pipe, name)
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"github.com/coding-ia/renovate-controller/internal/service"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReferencesField(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{text: `token: require('fs').readFileSync('{{ .TokenFile }}')`, want: true},
+		{text: `token: {{ toJs .TokenFile }}`, want: true},
+		{text: `{{ if .IsEnterprise }}{{ $.TokenFile }}{{ end }}`, want: true},
+		{text: `{{ range .Repositories }}{{ . }}{{ else }}{{ .TokenFile | quote }}{{ end }}`, want: true},
+		{text: `{{ define "token" }}{{ .TokenFile }}{{ end }}{{ template "token" . }}`, want: true},
+		{text: `token: '{{ .InstallationToken }}'`},
+		{text: `file: '{{ .TokenFileName }}'`},
+		{text: `plain text`},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := newConfigTemplate("config", tt.text, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := referencesField(tmpl, "TokenFile"); got != tt.want {
+			t.Errorf("referencesField(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateTokenHandoff(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(name string, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	tokenFileTemplate := writeTemplate("token-file.js", "module.exports = {platform: '{{ .Platform }}', token: require('fs').readFileSync({{ toJs .TokenFile }}, 'utf8').trim()};\n")
+	inlineTemplate := writeTemplate("inline.js", "module.exports = {platform: '{{ .Platform }}', token: '{{ .InstallationToken }}'};\n")
+
+	tests := []struct {
+		name  string
+		opts  GenerateCommandOptions
+		modes map[string]os.FileMode
+		err   string
+	}{
+		{
+			name:  "file handoff",
+			opts:  GenerateCommandOptions{Template: tokenFileTemplate, Output: "config.js", TokenHandoff: TokenHandoffFile},
+			modes: map[string]os.FileMode{"config.js": 0600, "token": 0600},
+		},
+		{
+			name:  "file handoff with group readable config",
+			opts:  GenerateCommandOptions{Template: tokenFileTemplate, Output: "config.js", TokenHandoff: TokenHandoffFile, FileMode: 0640},
+			modes: map[string]os.FileMode{"config.js": 0640, "token": 0600},
+		},
+		{
+			name:  "explicit secret file mode",
+			opts:  GenerateCommandOptions{Template: tokenFileTemplate, Output: "config.js", TokenHandoff: TokenHandoffFile, FileMode: 0640, SecretFileMode: 0640},
+			modes: map[string]os.FileMode{"config.js": 0640, "token": 0640},
+		},
+		{
+			name: "file handoff without token file reference",
+			opts: GenerateCommandOptions{Template: inlineTemplate, Output: "config.js", TokenHandoff: TokenHandoffFile},
+			err:  "requires the template to read the token from .TokenFile",
+		},
+		{
+			name: "file handoff with env format",
+			opts: GenerateCommandOptions{Output: "renovate.env", Format: FormatEnv, TokenHandoff: TokenHandoffFile},
+			err:  "file token handoff cannot be combined with the env format",
+		},
+		{
+			name:  "env handoff",
+			opts:  GenerateCommandOptions{Template: writeTemplate("env.js", "module.exports = {platform: '{{ .Platform }}'};\n"), Output: "config.js", EnvOutput: "renovate.env", TokenHandoff: TokenHandoffEnv, FileMode: 0640},
+			modes: map[string]os.FileMode{"config.js": 0640, "renovate.env": 0600},
+		},
+		{
+			name:  "env format",
+			opts:  GenerateCommandOptions{Output: "renovate.env", Format: FormatEnv, FileMode: 0640},
+			modes: map[string]os.FileMode{"renovate.env": 0600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := t.TempDir()
+			tt.opts.Output = filepath.Join(out, tt.opts.Output)
+			if tt.opts.EnvOutput != "" {
+				tt.opts.EnvOutput = filepath.Join(out, tt.opts.EnvOutput)
+			}
+
+			g := GenerateFuncCallback{Command: GenerateCommand{CommandOptions: tt.opts}}
+			err := g.GenerateConfig(service.InstallationContext{
+				Token:        "ghs_token",
+				Platform:     "github",
+				Endpoint:     "https://api.github.com/",
+				Repositories: []service.RepositoryMetadata{{FullName: "acme/widgets"}},
+			})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("GenerateConfig error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, want := range tt.modes {
+				info, err := os.Stat(filepath.Join(out, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != want {
+					t.Errorf("%s mode = %o, want %o", name, got, want)
+				}
+			}
+		})
+	}
+}
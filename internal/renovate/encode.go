@@ -0,0 +1,31 @@
+package renovate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Encode renders a config in the format implied by the extension of name:
+// a CommonJS module for .js/.cjs/.ts and plain JSON for .json/.json5.
+func Encode(name string, config map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".js", ".cjs", ".ts":
+		body := strings.TrimSuffix(buf.String(), "\n")
+		return []byte("module.exports = " + body + ";\n"), nil
+	case ".json", ".json5":
+		return buf.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported output format %q, expected .js, .cjs, .ts, .json or .json5", filepath.Ext(name))
+}
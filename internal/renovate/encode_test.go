@@ -0,0 +1,58 @@
+package renovate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	config := map[string]interface{}{
+		"platform": "github",
+		"extends":  []interface{}{"config:recommended"},
+		"prBody":   "<b>deps</b>",
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{name: "config.js", prefix: "module.exports = {"},
+		{name: "config.cjs", prefix: "module.exports = {"},
+		{name: "config.ts", prefix: "module.exports = {"},
+		{name: "CONFIG.TS", prefix: "module.exports = {"},
+		{name: "config.json", prefix: "{"},
+		{name: "config.json5", prefix: "{"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Encode(tt.name, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(data), tt.prefix) {
+				t.Errorf("Encode = %q, want prefix %q", data, tt.prefix)
+			}
+			if !strings.Contains(string(data), "<b>deps</b>") {
+				t.Errorf("Encode escaped HTML: %q", data)
+			}
+
+			parsed, err := ParseConfig(tt.name, data)
+			if err != nil {
+				t.Fatalf("ParseConfig error: %v", err)
+			}
+			if !reflect.DeepEqual(parsed, config) {
+				t.Errorf("round trip = %#v, want %#v", parsed, config)
+			}
+		})
+	}
+}
+
+func TestEncodeUnsupported(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.mjs", "config"} {
+		if _, err := Encode(name, map[string]interface{}{}); err == nil {
+			t.Errorf("Encode(%q) succeeded, want an error", name)
+		}
+	}
+}
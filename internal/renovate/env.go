@@ -0,0 +1,164 @@
+package renovate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	EnvQuoteShell  = "shell"
+	EnvQuoteDotenv = "dotenv"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// EncodeEnv renders variables as an env file. Shell style uses single quotes
+// so the file can be sourced by a POSIX shell; dotenv style uses double
+// quotes with backslash escapes as understood by dotenv parsers.
+func EncodeEnv(vars []EnvVar, style string) ([]byte, error) {
+	var quoteValue func(string) string
+	switch style {
+	case "", EnvQuoteShell:
+		quoteValue = shellQuote
+	case EnvQuoteDotenv:
+		quoteValue = dotenvQuote
+	default:
+		return nil, fmt.Errorf("unknown env quoting style '%s'", style)
+	}
+
+	buf := new(bytes.Buffer)
+	for _, v := range vars {
+		if !envNamePattern.MatchString(v.Name) {
+			return nil, fmt.Errorf("invalid environment variable name '%s'", v.Name)
+		}
+		fmt.Fprintf(buf, "%s=%s\n", v.Name, quoteValue(v.Value))
+	}
+	return buf.Bytes(), nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func dotenvQuote(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// ParseEnv reads KEY=VALUE lines. Blank lines, comments and an optional
+// "export " prefix are ignored. Values may use shell single quotes, including
+// the escaped quotes written by EncodeEnv, or dotenv double quotes, and quoted
+// values may span several lines.
+func ParseEnv(content string) ([]EnvVar, error) {
+	var vars []EnvVar
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		start := i + 1
+		text := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		name, value, found := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !found || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid env line %d", start)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		unquoted, err := unquoteEnv(value)
+		for errors.Is(err, errUnterminated) && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+			unquoted, err = unquoteEnv(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid env line %d: %v", start, err)
+		}
+		vars = append(vars, EnvVar{Name: name, Value: unquoted})
+	}
+
+	return vars, nil
+}
+
+var errUnterminated = errors.New("unterminated quoted value")
+
+// unquoteEnv removes shell and dotenv quoting from a value. Unquoted values
+// are returned as they are, apart from trailing whitespace.
+func unquoteEnv(value string) (string, error) {
+	if value == "" || (value[0] != '\'' && value[0] != '"') {
+		return strings.TrimRight(value, " \t\r"), nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		switch c := value[i]; c {
+		case '\'':
+			end := strings.IndexByte(value[i+1:], '\'')
+			if end < 0 {
+				return "", errUnterminated
+			}
+			sb.WriteString(value[i+1 : i+1+end])
+			i += end + 2
+		case '"':
+			end := -1
+			for j := i + 1; j < len(value); j++ {
+				if value[j] == '\\' && j+1 < len(value) {
+					j++
+					switch value[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 'r':
+						sb.WriteByte('\r')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(value[j])
+					}
+					continue
+				}
+				if value[j] == '"' {
+					end = j
+					break
+				}
+				sb.WriteByte(value[j])
+			}
+			if end < 0 {
+				return "", errUnterminated
+			}
+			i = end + 1
+		case '\\':
+			if i+1 < len(value) {
+				sb.WriteByte(value[i+1])
+			}
+			i += 2
+		case ' ', '\t', '\r':
+			// Whitespace ends the value; only a comment may follow it.
+			if rest := strings.TrimLeft(value[i:], " \t\r"); rest != "" && rest[0] != '#' {
+				return "", fmt.Errorf("unexpected %q after quoted value", rest)
+			}
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,150 @@
+package renovate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeEnv(t *testing.T) {
+	vars := []EnvVar{
+		{Name: "PLAIN", Value: "github"},
+		{Name: "QUOTE", Value: `it's "quoted"`},
+		{Name: "DOLLAR", Value: "$HOME `id` \\n"},
+		{Name: "NEWLINE", Value: "one\ntwo"},
+	}
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{style: "", want: `PLAIN='github'
+QUOTE='it'\''s "quoted"'
+DOLLAR='$HOME ` + "`id`" + ` \n'
+NEWLINE='one
+two'
+`},
+		{style: EnvQuoteDotenv, want: `PLAIN="github"
+QUOTE="it's \"quoted\""
+DOLLAR="\$HOME ` + "`id`" + ` \\n"
+NEWLINE="one\ntwo"
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			got, err := EncodeEnv(vars, tt.style)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EncodeEnv =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeEnvErrors(t *testing.T) {
+	if _, err := EncodeEnv([]EnvVar{{Name: "1BAD", Value: "x"}}, EnvQuoteShell); err == nil {
+		t.Error("EncodeEnv accepted an invalid name")
+	}
+	if _, err := EncodeEnv(nil, "yaml"); err == nil {
+		t.Error("EncodeEnv accepted an unknown quoting style")
+	}
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"github",
+		"with spaces ",
+		`it's`,
+		`''`,
+		`"double" and 'single'`,
+		`back\slash\`,
+		"$HOME ${USER} `id` $(id)",
+		"# not a comment",
+		"one\ntwo\n",
+		"carriage\r\nreturn",
+		"tab\there",
+		`["acme/widgets","acme/gadgets"]`,
+		`[{"matchHost":"npm.pkg.github.com","token":"p@ss=word"}]`,
+		"unicode é ✓",
+	}
+
+	for _, style := range []string{EnvQuoteShell, EnvQuoteDotenv} {
+		t.Run(style, func(t *testing.T) {
+			var vars []EnvVar
+			for i, value := range values {
+				vars = append(vars, EnvVar{Name: "VAR_" + string(rune('A'+i)), Value: value})
+			}
+
+			data, err := EncodeEnv(vars, style)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseEnv(string(data))
+			if err != nil {
+				t.Fatalf("ParseEnv error: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(parsed, vars) {
+				t.Errorf("round trip =\n%q\nwant\n%q", parsed, vars)
+			}
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []EnvVar
+	}{
+		{name: "unquoted", input: "A=b\nC = d  \n", want: []EnvVar{{"A", "b"}, {"C", "d"}}},
+		{name: "comments and blanks", input: "# comment\n\n  # indented\nA=b\n", want: []EnvVar{{"A", "b"}}},
+		{name: "export prefix", input: "export A='b c'", want: []EnvVar{{"A", "b c"}}},
+		{name: "empty values", input: "A=\nB=''\nC=\"\"", want: []EnvVar{{"A", ""}, {"B", ""}, {"C", ""}}},
+		{name: "crlf", input: "A=b\r\nC='d'\r\n", want: []EnvVar{{"A", "b"}, {"C", "d"}}},
+		{name: "comment after quoted value", input: `A='b' # note`, want: []EnvVar{{"A", "b"}}},
+		{name: "shell concatenation", input: `A='it'\''s'`, want: []EnvVar{{"A", "it's"}}},
+		{name: "dotenv escapes", input: `A="a\tb\"c\\d\$e"`, want: []EnvVar{{"A", "a\tb\"c\\d$e"}}},
+		{name: "multi-line single quoted", input: "A='one\n\n# two'\nB=c", want: []EnvVar{{"A", "one\n\n# two"}, {"B", "c"}}},
+		{name: "multi-line double quoted", input: "A=\"one\ntwo\"", want: []EnvVar{{"A", "one\ntwo"}}},
+		{name: "equals in value", input: "A=b=c", want: []EnvVar{{"A", "b=c"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEnv(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEnv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "missing equals", input: "A=b\nNAME", err: "invalid env line 2"},
+		{name: "invalid name", input: "1A=b", err: "invalid env line 1"},
+		{name: "unterminated single quote", input: "A=b\nB='open\nC=d", err: "invalid env line 2: unterminated"},
+		{name: "unterminated double quote", input: `A="open`, err: "unterminated"},
+		{name: "text after quoted value", input: `A='b' c`, err: "invalid env line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseEnv(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseEnv error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package renovate
+
+// mergeableArrays lists options whose arrays are concatenated instead of
+// replaced when configs are merged, mirroring Renovate's own preset merging.
+var mergeableArrays = map[string]bool{
+	"addLabels":      true,
+	"customManagers": true,
+	"extends":        true,
+	"hostRules":      true,
+	"ignoreDeps":     true,
+	"ignorePaths":    true,
+	"ignorePresets":  true,
+	"packageRules":   true,
+	"regexManagers":  true,
+}
+
+// Merge deep-merges overlay into base and returns the result. Neither input
+// is modified.
+func Merge(base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overlay {
+		existing, found := result[key]
+		if !found {
+			result[key] = value
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if existingMap, ok := existing.(map[string]interface{}); ok {
+				result[key] = Merge(existingMap, v)
+				continue
+			}
+		case []interface{}:
+			if existingList, ok := existing.([]interface{}); ok && mergeableArrays[key] {
+				result[key] = appendUnique(existingList, v)
+				continue
+			}
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+func appendUnique(base []interface{}, values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(base)+len(values))
+	result = append(result, base...)
+
+	for _, value := range values {
+		if isScalar(value) && contains(result, value) {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+func contains(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if isScalar(item) && item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package renovate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    map[string]interface{}
+		overlay map[string]interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "scalars are replaced",
+			base:    map[string]interface{}{"timezone": "UTC", "prHourlyLimit": 2},
+			overlay: map[string]interface{}{"timezone": "Europe/Berlin"},
+			want:    map[string]interface{}{"timezone": "Europe/Berlin", "prHourlyLimit": 2},
+		},
+		{
+			name:    "mergeable arrays are concatenated",
+			base:    map[string]interface{}{"extends": []interface{}{"config:recommended"}},
+			overlay: map[string]interface{}{"extends": []interface{}{":semanticCommits"}},
+			want:    map[string]interface{}{"extends": []interface{}{"config:recommended", ":semanticCommits"}},
+		},
+		{
+			name:    "other arrays are replaced",
+			base:    map[string]interface{}{"schedule": []interface{}{"before 6am"}, "labels": []interface{}{"deps"}},
+			overlay: map[string]interface{}{"schedule": []interface{}{"every weekend"}, "labels": []interface{}{"renovate"}},
+			want:    map[string]interface{}{"schedule": []interface{}{"every weekend"}, "labels": []interface{}{"renovate"}},
+		},
+		{
+			name:    "duplicate scalars are dropped",
+			base:    map[string]interface{}{"ignoreDeps": []interface{}{"lodash", "react"}},
+			overlay: map[string]interface{}{"ignoreDeps": []interface{}{"react", "vue", "vue"}},
+			want:    map[string]interface{}{"ignoreDeps": []interface{}{"lodash", "react", "vue"}},
+		},
+		{
+			name: "objects in mergeable arrays are kept",
+			base: map[string]interface{}{"packageRules": []interface{}{
+				map[string]interface{}{"matchPackageNames": []interface{}{"react"}, "automerge": true},
+			}},
+			overlay: map[string]interface{}{"packageRules": []interface{}{
+				map[string]interface{}{"matchPackageNames": []interface{}{"react"}, "automerge": true},
+			}},
+			want: map[string]interface{}{"packageRules": []interface{}{
+				map[string]interface{}{"matchPackageNames": []interface{}{"react"}, "automerge": true},
+				map[string]interface{}{"matchPackageNames": []interface{}{"react"}, "automerge": true},
+			}},
+		},
+		{
+			name:    "objects are merged recursively",
+			base:    map[string]interface{}{"lockFileMaintenance": map[string]interface{}{"enabled": false, "schedule": []interface{}{"before 6am"}}},
+			overlay: map[string]interface{}{"lockFileMaintenance": map[string]interface{}{"enabled": true}},
+			want:    map[string]interface{}{"lockFileMaintenance": map[string]interface{}{"enabled": true, "schedule": []interface{}{"before 6am"}}},
+		},
+		{
+			name:    "type changes replace the value",
+			base:    map[string]interface{}{"extends": "config:recommended", "vulnerabilityAlerts": map[string]interface{}{"enabled": true}},
+			overlay: map[string]interface{}{"extends": []interface{}{"config:base"}, "vulnerabilityAlerts": false},
+			want:    map[string]interface{}{"extends": []interface{}{"config:base"}, "vulnerabilityAlerts": false},
+		},
+		{
+			name:    "nil base",
+			overlay: map[string]interface{}{"platform": "github"},
+			want:    map[string]interface{}{"platform": "github"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.base, tt.overlay); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotModifyInputs(t *testing.T) {
+	base := map[string]interface{}{
+		"extends": []interface{}{"config:recommended"},
+		"nested":  map[string]interface{}{"a": 1},
+	}
+	overlay := map[string]interface{}{
+		"extends": []interface{}{":semanticCommits"},
+		"nested":  map[string]interface{}{"b": 2},
+	}
+
+	Merge(base, overlay)
+
+	want := map[string]interface{}{
+		"extends": []interface{}{"config:recommended"},
+		"nested":  map[string]interface{}{"a": 1},
+	}
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("base was modified: %v", base)
+	}
+	if !reflect.DeepEqual(overlay["nested"], map[string]interface{}{"b": 2}) {
+		t.Errorf("overlay was modified: %v", overlay)
+	}
+}
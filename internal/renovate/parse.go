@@ -0,0 +1,78 @@
+package renovate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/json5"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ErrNotStatic = errors.New("config is not a static object literal")
+
+var exportPattern = regexp.MustCompile(`(?s)^(?:module\.exports\s*=|export\s+default)\s*(.*?)\s*;?\s*$`)
+
+func IsConfigFormat(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".json5", ".js", ".cjs", ".mjs", ".ts":
+		return true
+	}
+	return false
+}
+
+// ParseConfig parses a rendered Renovate configuration based on the file
+// extension of name. JavaScript configs are only parsed when they export a
+// plain object literal; otherwise ErrNotStatic is returned. Other names, such
+// as .renovaterc, are parsed as JSON5, which also accepts plain JSON.
+func ParseConfig(name string, data []byte) (map[string]interface{}, error) {
+	var value interface{}
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &value)
+	case ".js", ".cjs", ".mjs", ".ts":
+		match := exportPattern.FindSubmatch([]byte(stripLeadingComments(string(data))))
+		if match == nil {
+			return nil, ErrNotStatic
+		}
+		value, err = json5.Parse(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotStatic, err)
+		}
+	default:
+		value, err = json5.Parse(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config must be an object")
+	}
+	return config, nil
+}
+
+func stripLeadingComments(text string) string {
+	for {
+		text = strings.TrimSpace(text)
+		switch {
+		case strings.HasPrefix(text, "//"):
+			_, rest, _ := strings.Cut(text, "\n")
+			text = rest
+		case strings.HasPrefix(text, "/*"):
+			_, rest, found := strings.Cut(text, "*/")
+			if !found {
+				return text
+			}
+			text = rest
+		case strings.HasPrefix(text, "'use strict'"), strings.HasPrefix(text, `"use strict"`):
+			text = strings.TrimPrefix(text[len("'use strict'"):], ";")
+		default:
+			return text
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package renovate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	want := map[string]interface{}{"platform": "github", "extends": []interface{}{"config:recommended"}}
+
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{name: "json", file: "config.json", data: `{"platform": "github", "extends": ["config:recommended"]}`},
+		{name: "json5", file: "config.json5", data: `{platform: 'github', extends: ['config:recommended'],}`},
+		{name: "renovaterc without extension", file: ".renovaterc", data: `{"platform": "github", "extends": ["config:recommended"]}`},
+		{name: "unknown extension as json5", file: "renovate.conf", data: `// comment
+{platform: 'github', extends: ['config:recommended']}`},
+		{name: "commonjs", file: "config.js", data: `module.exports = {
+  platform: 'github',
+  extends: ['config:recommended'],
+};`},
+		{name: "commonjs without semicolon", file: "config.cjs", data: `module.exports = {platform: "github", extends: ["config:recommended"]}`},
+		{name: "use strict and comments", file: "config.js", data: `/* generated */
+// do not edit
+'use strict';
+module.exports = {platform: 'github', extends: ['config:recommended']};`},
+		{name: "es module", file: "config.mjs", data: `export default {platform: 'github', extends: ['config:recommended']};`},
+		{name: "typescript", file: "config.ts", data: `export default {platform: 'github', extends: ['config:recommended']};`},
+		{name: "uppercase extension", file: "CONFIG.JSON", data: `{"platform": "github", "extends": ["config:recommended"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfig(tt.file, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseConfig error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseConfig = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseConfigNotStatic(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "computed config", data: `const config = {platform: 'github'};
+module.exports = config;`},
+		{name: "function call", data: `module.exports = {token: process.env.TOKEN};`},
+		{name: "require", data: `module.exports = {token: require('fs').readFileSync('/data/token', 'utf8')};`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig("config.js", []byte(tt.data))
+			if !errors.Is(err, ErrNotStatic) {
+				t.Errorf("ParseConfig error = %v, want ErrNotStatic", err)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		err  string
+	}{
+		{name: "invalid json", file: "config.json", data: `{"platform": }`, err: "invalid character"},
+		{name: "invalid json5", file: "config.json5", data: `{platform: }`, err: "json5:"},
+		{name: "array config", file: "config.json", data: `["github"]`, err: "config must be an object"},
+		{name: "scalar config", file: ".renovaterc", data: `'github'`, err: "config must be an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.file, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseConfig error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestIsConfigFormat(t *testing.T) {
+	for name, want := range map[string]bool{
+		"config.js":    true,
+		"config.cjs":   true,
+		"config.mjs":   true,
+		"config.ts":    true,
+		"config.json":  true,
+		"config.json5": true,
+		"renovate.env": false,
+		"settings.xml": false,
+		".npmrc":       false,
+	} {
+		if got := IsConfigFormat(name); got != want {
+			t.Errorf("IsConfigFormat(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package renovate
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+//go:embed schema.json
+var schemaData []byte
+
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+type property struct {
+	Type typeList      `json:"type"`
+	Enum []interface{} `json:"enum"`
+}
+
+type schema struct {
+	Properties map[string]property `json:"properties"`
+}
+
+var globalSchema = mustLoadSchema()
+
+func mustLoadSchema() schema {
+	var s schema
+	if err := json.Unmarshal(schemaData, &s); err != nil {
+		panic(fmt.Sprintf("invalid embedded renovate schema: %v", err))
+	}
+	return s
+}
+
+// Validate checks the known top-level options of a Renovate configuration
+// against the embedded schema. Unknown options are returned as warnings
+// because the schema only covers a subset of Renovate's options.
+func Validate(config map[string]interface{}) ([]string, error) {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	var errs []error
+	for _, key := range keys {
+		prop, known := globalSchema.Properties[key]
+		if !known {
+			warnings = append(warnings, fmt.Sprintf("unknown option %q", key))
+			continue
+		}
+
+		value := config[key]
+		if len(prop.Type) > 0 && !matchesType(value, prop.Type) {
+			errs = append(errs, fmt.Errorf("option %q must be of type %s, got %s", key, strings.Join(prop.Type, " or "), typeName(value)))
+			continue
+		}
+		if len(prop.Enum) > 0 && !matchesEnum(value, prop.Enum) {
+			errs = append(errs, fmt.Errorf("option %q has invalid value %v", key, value))
+		}
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if typeName(value) == t {
+			return true
+		}
+		if t == "number" && typeName(value) == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case int, int32, int64:
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,102 @@
+package renovate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		warnings []string
+		errs     []string
+	}{
+		{
+			name: "valid",
+			config: map[string]interface{}{
+				"platform":      "github",
+				"endpoint":      "https://api.github.com/",
+				"extends":       []interface{}{"config:recommended"},
+				"prHourlyLimit": 2.0,
+				"onboarding":    false,
+				"hostRules":     []interface{}{},
+			},
+		},
+		{
+			name:     "unknown options are warnings",
+			config:   map[string]interface{}{"zeta": 1.0, "alpha": true},
+			warnings: []string{`unknown option "alpha"`, `unknown option "zeta"`},
+		},
+		{
+			name:   "wrong type",
+			config: map[string]interface{}{"onboarding": "yes"},
+			errs:   []string{`option "onboarding" must be of type boolean, got string`},
+		},
+		{
+			name:   "fraction for integer",
+			config: map[string]interface{}{"prHourlyLimit": 1.5},
+			errs:   []string{`option "prHourlyLimit" must be of type integer, got number`},
+		},
+		{
+			name:   "null value",
+			config: map[string]interface{}{"gitAuthor": nil},
+			errs:   []string{`option "gitAuthor" must be of type string, got null`},
+		},
+		{
+			name:   "invalid enum",
+			config: map[string]interface{}{"platform": "sourceforge"},
+			errs:   []string{`option "platform" has invalid value sourceforge`},
+		},
+		{
+			name:   "multiple types",
+			config: map[string]interface{}{"autodiscoverFilter": []interface{}{"acme/*"}},
+		},
+		{
+			name:   "multiple errors",
+			config: map[string]interface{}{"onboarding": 1.0, "extends": "config:recommended"},
+			errs: []string{
+				`option "extends" must be of type array, got string`,
+				`option "onboarding" must be of type boolean, got integer`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := Validate(tt.config)
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.warnings)
+			}
+
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q", tt.errs)
+			}
+			if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, tt.errs) {
+				t.Errorf("errors = %q, want %q", got, tt.errs)
+			}
+		})
+	}
+}
+
+func TestValidateParsedConfig(t *testing.T) {
+	config, err := ParseConfig("config.js", []byte(`module.exports = {
+  platform: 'github',
+  prHourlyLimit: 0x2,
+  repositories: ['acme/widgets'],
+};`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Validate(config); err != nil {
+		t.Errorf("Validate error: %v", err)
+	}
+}
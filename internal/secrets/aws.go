@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"strings"
+)
+
+type SecretsAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+type ParameterAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// secretsManagerProvider handles awssm://<secret-id>?versionStage=<stage>&versionId=<id>.
+type secretsManagerProvider struct {
+	client SecretsAPI
+}
+
+func NewSecretsManagerProvider(client SecretsAPI) Provider {
+	return secretsManagerProvider{client: client}
+}
+
+func (p secretsManagerProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	if p.client == nil {
+		return "", fmt.Errorf("no Secrets Manager client configured")
+	}
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(ref.Path),
+	}
+	if stage := ref.Query.Get("versionStage"); stage != "" {
+		input.VersionStage = aws.String(stage)
+	}
+	if versionID := ref.Query.Get("versionId"); versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	result, err := p.client.GetSecretValue(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	if result.SecretString == nil && result.SecretBinary != nil {
+		return string(result.SecretBinary), nil
+	}
+	return aws.ToString(result.SecretString), nil
+}
+
+// parameterStoreProvider handles ssm://<parameter-name>, decrypting SecureString values.
+type parameterStoreProvider struct {
+	client ParameterAPI
+}
+
+func NewParameterStoreProvider(client ParameterAPI) Provider {
+	return parameterStoreProvider{client: client}
+}
+
+func (p parameterStoreProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	if p.client == nil {
+		return "", fmt.Errorf("no SSM client configured")
+	}
+
+	name := ref.Path
+	if name[0] != '/' && strings.Contains(name, "/") && !strings.HasPrefix(name, "arn:") {
+		name = "/" + name
+	}
+
+	output, err := p.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get SSM parameter, %v", err)
+	}
+
+	return aws.ToString(output.Parameter.Value), nil
+}
@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeSecretsManager struct {
+	output *secretsmanager.GetSecretValueOutput
+	err    error
+	inputs []*secretsmanager.GetSecretValueInput
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.inputs = append(f.inputs, params)
+	return f.output, f.err
+}
+
+type fakeParameterStore struct {
+	value  string
+	err    error
+	inputs []*ssm.GetParameterInput
+}
+
+func (f *fakeParameterStore) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.inputs = append(f.inputs, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(f.value)}}, nil
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		uri    string
+		output *secretsmanager.GetSecretValueOutput
+		want   string
+		input  *secretsmanager.GetSecretValueInput
+	}{
+		{
+			name:   "plain secret id",
+			uri:    "github-app-pem",
+			output: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("pem")},
+			want:   "pem",
+			input:  &secretsmanager.GetSecretValueInput{SecretId: aws.String("github-app-pem")},
+		},
+		{
+			name:   "version stage",
+			uri:    "awssm://github-app-pem?versionStage=AWSPENDING",
+			output: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("pending")},
+			want:   "pending",
+			input:  &secretsmanager.GetSecretValueInput{SecretId: aws.String("github-app-pem"), VersionStage: aws.String("AWSPENDING")},
+		},
+		{
+			name:   "version id and key",
+			uri:    "awssm://github-app?versionId=v2&key=pem",
+			output: &secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"pem":"from-json"}`)},
+			want:   "from-json",
+			input:  &secretsmanager.GetSecretValueInput{SecretId: aws.String("github-app"), VersionId: aws.String("v2")},
+		},
+		{
+			name:   "binary secret",
+			uri:    "awssm://binary",
+			output: &secretsmanager.GetSecretValueOutput{SecretBinary: []byte("binary pem")},
+			want:   "binary pem",
+			input:  &secretsmanager.GetSecretValueInput{SecretId: aws.String("binary")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSecretsManager{output: tt.output}
+			providers := NewProviders(client, nil, nil, nil)
+
+			got, err := providers.GetSecret(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GetSecret = %q, want %q", got, tt.want)
+			}
+			if len(client.inputs) != 1 || !reflect.DeepEqual(client.inputs[0], tt.input) {
+				t.Errorf("GetSecretValue input = %+v, want %+v", client.inputs, tt.input)
+			}
+		})
+	}
+}
+
+func TestParameterStoreProvider(t *testing.T) {
+	tests := []struct {
+		uri  string
+		name string
+	}{
+		{uri: "ssm://renovate/github-app/pem", name: "/renovate/github-app/pem"},
+		{uri: "ssm:///renovate/pem", name: "/renovate/pem"},
+		{uri: "ssm://github-app-pem", name: "github-app-pem"},
+		{uri: "ssm://arn:aws:ssm:us-east-2:123456789012:parameter/renovate/pem", name: "arn:aws:ssm:us-east-2:123456789012:parameter/renovate/pem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			client := &fakeParameterStore{value: "pem"}
+			providers := NewProviders(nil, client, nil, nil)
+
+			got, err := providers.GetSecret(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "pem" {
+				t.Errorf("GetSecret = %q, want pem", got)
+			}
+			if len(client.inputs) != 1 {
+				t.Fatalf("GetParameter called %d times", len(client.inputs))
+			}
+			if name := aws.ToString(client.inputs[0].Name); name != tt.name {
+				t.Errorf("parameter name = %q, want %q", name, tt.name)
+			}
+			if !aws.ToBool(client.inputs[0].WithDecryption) {
+				t.Error("parameter was read without decryption")
+			}
+		})
+	}
+}
+
+func TestAWSProviderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		uri      string
+		err      string
+	}{
+		{name: "secrets manager without client", provider: NewSecretsManagerProvider(nil), uri: "awssm://secret", err: "no Secrets Manager client configured"},
+		{name: "parameter store without client", provider: NewParameterStoreProvider(nil), uri: "ssm://parameter", err: "no SSM client configured"},
+		{name: "secrets manager error", provider: NewSecretsManagerProvider(&fakeSecretsManager{err: errors.New("AccessDeniedException")}), uri: "awssm://secret", err: "AccessDeniedException"},
+		{name: "parameter store error", provider: NewParameterStoreProvider(&fakeParameterStore{err: errors.New("ParameterNotFound")}), uri: "ssm://parameter", err: "failed to get SSM parameter, ParameterNotFound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseReference(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = tt.provider.GetSecret(context.Background(), ref)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("GetSecret error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
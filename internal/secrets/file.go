@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultKubernetesSecretsDir = "/etc/secrets"
+
+type fileProvider struct{}
+
+func (fileProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	return readSecretFile(ref.Path)
+}
+
+type envProvider struct{}
+
+func (envProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	value, found := os.LookupEnv(ref.Path)
+	if !found {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Path)
+	}
+	return value, nil
+}
+
+// kubernetesProvider reads a secret mounted as a volume. k8s://<name>/<key>
+// resolves below K8S_SECRETS_DIR (default /etc/secrets); k8s:///<path> reads
+// an absolute path.
+type kubernetesProvider struct{}
+
+func (kubernetesProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	if filepath.IsAbs(ref.Path) {
+		return readSecretFile(ref.Path)
+	}
+
+	name, key, found := strings.Cut(ref.Path, "/")
+	if !found || name == "" || key == "" {
+		return "", fmt.Errorf("invalid kubernetes secret reference %q, expected k8s://<name>/<key>", ref.Path)
+	}
+
+	dir := os.Getenv("K8S_SECRETS_DIR")
+	if dir == "" {
+		dir = defaultKubernetesSecretsDir
+	}
+	return readSecretFile(filepath.Join(dir, name, key))
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file, %v", err)
+	}
+	return string(data), nil
+}
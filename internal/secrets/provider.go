@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Reference is a parsed secret URI such as awssm://my-secret?key=pem.
+type Reference struct {
+	Scheme string
+	Path   string
+	Query  url.Values
+}
+
+type Provider interface {
+	GetSecret(ctx context.Context, ref Reference) (string, error)
+}
+
+// Providers maps URI schemes to the provider reading them. Commands build
+// one set with their clients and pass it to everything that reads secrets.
+type Providers map[string]Provider
+
+// NewProviders returns providers for every supported scheme. The AWS
+// clients may be nil, awssm:// and ssm:// references then fail when read.
+// httpClient and credentials are used by the vault:// provider.
+func NewProviders(secretsClient SecretsAPI, parameterClient ParameterAPI, httpClient *http.Client, credentials aws.CredentialsProvider) Providers {
+	return Providers{
+		"file":  fileProvider{},
+		"env":   envProvider{},
+		"k8s":   kubernetesProvider{},
+		"awssm": NewSecretsManagerProvider(secretsClient),
+		"ssm":   NewParameterStoreProvider(parameterClient),
+		"vault": NewVaultProvider(httpClient, credentials),
+	}
+}
+
+func ParseReference(uri string) (Reference, error) {
+	scheme, rest, found := strings.Cut(uri, "://")
+	if !found {
+		// Plain secret names and ARNs refer to Secrets Manager.
+		scheme, rest = "awssm", uri
+	}
+
+	path, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid secret reference %q: %v", uri, err)
+	}
+	if path == "" {
+		return Reference{}, fmt.Errorf("invalid secret reference %q: empty path", uri)
+	}
+
+	return Reference{
+		Scheme: strings.ToLower(scheme),
+		Path:   path,
+		Query:  query,
+	}, nil
+}
+
+// Resolve reads the secret identified by uri. Supported schemes are file://,
+// env://, k8s://, awssm://, ssm:// and vault://; a value without a scheme is
+// treated as a Secrets Manager secret ID. A "key" query parameter selects a
+// field of a JSON secret.
+func (p Providers) Resolve(ctx context.Context, uri string) (string, error) {
+	ref, err := ParseReference(uri)
+	if err != nil {
+		return "", err
+	}
+
+	provider, found := p[ref.Scheme]
+	if !found {
+		return "", fmt.Errorf("unsupported secret scheme %q", ref.Scheme)
+	}
+
+	value, err := provider.GetSecret(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	key := ref.Query.Get("key")
+	if key == "" || ref.Scheme == "vault" {
+		return value, nil
+	}
+	return ExtractKey(value, key)
+}
+
+// ExtractKey returns the field key of a JSON object secret. String fields
+// are returned as is, other values JSON encoded.
+func ExtractKey(value string, key string) (string, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %v", err)
+	}
+
+	field, found := fields[key]
+	if !found {
+		return "", fmt.Errorf("secret has no key '%s'", key)
+	}
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+
+	encoded, err := json.Marshal(field)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
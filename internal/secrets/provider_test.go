@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type staticProvider struct {
+	value string
+	refs  []Reference
+}
+
+func (p *staticProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	p.refs = append(p.refs, ref)
+	return p.value, nil
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want Reference
+	}{
+		{uri: "my-secret", want: Reference{Scheme: "awssm", Path: "my-secret", Query: url.Values{}}},
+		{uri: "arn:aws:secretsmanager:us-east-2:123456789012:secret:pem-AbCdEf", want: Reference{Scheme: "awssm", Path: "arn:aws:secretsmanager:us-east-2:123456789012:secret:pem-AbCdEf", Query: url.Values{}}},
+		{uri: "AWSSM://my-secret?versionStage=AWSPENDING", want: Reference{Scheme: "awssm", Path: "my-secret", Query: url.Values{"versionStage": {"AWSPENDING"}}}},
+		{uri: "file:///etc/renovate/key.pem", want: Reference{Scheme: "file", Path: "/etc/renovate/key.pem", Query: url.Values{}}},
+		{uri: "vault://secret/renovate?key=pem&version=2", want: Reference{Scheme: "vault", Path: "secret/renovate", Query: url.Values{"key": {"pem"}, "version": {"2"}}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.uri)
+		if err != nil {
+			t.Errorf("ParseReference(%q) error: %v", tt.uri, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestParseReferenceErrors(t *testing.T) {
+	for _, uri := range []string{"", "file://", "env://?key=x", "awssm://secret?key=%zz"} {
+		if _, err := ParseReference(uri); err == nil {
+			t.Errorf("ParseReference(%q) succeeded, want an error", uri)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	provider := &staticProvider{value: `{"pem":"-----BEGIN-----","port":8200,"nested":{"a":1}}`}
+	providers := Providers{"test": provider}
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "test://json", want: `{"pem":"-----BEGIN-----","port":8200,"nested":{"a":1}}`},
+		{uri: "test://json?key=pem", want: "-----BEGIN-----"},
+		{uri: "test://json?key=port", want: "8200"},
+		{uri: "test://json?key=nested", want: `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		got, err := providers.Resolve(context.Background(), tt.uri)
+		if err != nil {
+			t.Errorf("Resolve(%q) error: %v", tt.uri, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+	if len(provider.refs) != len(tests) || provider.refs[0].Path != "json" {
+		t.Errorf("provider received %+v", provider.refs)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	providers := NewProviders(nil, nil, nil, nil)
+	providers["test"] = &staticProvider{value: "plain text"}
+
+	tests := []struct {
+		uri string
+		err string
+	}{
+		{uri: "unknown://secret", err: `unsupported secret scheme "unknown"`},
+		{uri: "test://secret?key=pem", err: "secret is not a JSON object"},
+		{uri: "env://RENOVATE_CONTROLLER_TEST_UNSET", err: "is not set"},
+		{uri: "awssm://secret", err: "no Secrets Manager client configured"},
+		{uri: "ssm:///secret", err: "no SSM client configured"},
+	}
+
+	for _, tt := range tests {
+		_, err := providers.Resolve(context.Background(), tt.uri)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Resolve(%q) error = %v, want it to contain %q", tt.uri, err, tt.err)
+		}
+	}
+}
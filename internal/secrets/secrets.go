@@ -2,26 +2,8 @@ package secrets
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 )
 
-func GetSecret(secretID string) (string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return "", err
-	}
-
-	svc := secretsmanager.NewFromConfig(cfg)
-
-	result, err := svc.GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(secretID),
-	})
-	if err != nil {
-		return "", err
-	}
-
-	secretString := aws.ToString(result.SecretString)
-	return secretString, nil
+func (p Providers) GetSecret(secretID string) (string, error) {
+	return p.Resolve(context.TODO(), secretID)
 }
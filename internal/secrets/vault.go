@@ -0,0 +1,243 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vaultAuthToken   = "token"
+	vaultAuthAppRole = "approle"
+	vaultAuthAWS     = "aws"
+
+	stsRequestBody = "Action=GetCallerIdentity&Version=2011-06-15"
+)
+
+// vaultProvider reads KV v2 secrets as vault://<mount>/<path>?key=<field>.
+// The server and credentials come from the usual VAULT_* environment:
+// VAULT_ADDR, VAULT_NAMESPACE and either VAULT_TOKEN, VAULT_ROLE_ID and
+// VAULT_SECRET_ID for AppRole, or VAULT_AWS_ROLE for the AWS IAM method.
+// VAULT_AUTH_METHOD forces a method when several are configured.
+type vaultProvider struct {
+	mu          sync.Mutex
+	token       string
+	client      *http.Client
+	credentials aws.CredentialsProvider
+}
+
+// NewVaultProvider returns a vault:// provider using client for requests to
+// Vault. credentials sign the login request of the AWS IAM method.
+func NewVaultProvider(client *http.Client, credentials aws.CredentialsProvider) Provider {
+	return &vaultProvider{client: client, credentials: credentials}
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+type vaultLoginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+func (v *vaultProvider) GetSecret(ctx context.Context, ref Reference) (string, error) {
+	mount, path, found := strings.Cut(ref.Path, "/")
+	if !found || mount == "" || path == "" {
+		return "", fmt.Errorf("invalid vault reference %q, expected vault://<mount>/<path>", ref.Path)
+	}
+
+	token, err := v.login(ctx)
+	if err != nil {
+		return "", fmt.Errorf("vault login failed: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("/v1/%s/data/%s", mount, path)
+	if version := ref.Query.Get("version"); version != "" {
+		endpoint += "?version=" + version
+	}
+
+	var response vaultKVResponse
+	err = v.do(ctx, http.MethodGet, endpoint, token, nil, &response)
+	if err != nil {
+		return "", err
+	}
+
+	data := response.Data.Data
+	key := ref.Query.Get("key")
+	if key == "" && len(data) == 1 {
+		for _, value := range data {
+			return fmt.Sprint(value), nil
+		}
+	}
+	if key == "" {
+		encoded, err := json.Marshal(data)
+		return string(encoded), err
+	}
+
+	value, found := data[key]
+	if !found {
+		return "", fmt.Errorf("vault secret %s has no key '%s'", ref.Path, key)
+	}
+	return fmt.Sprint(value), nil
+}
+
+func (v *vaultProvider) login(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" {
+		return v.token, nil
+	}
+
+	method := os.Getenv("VAULT_AUTH_METHOD")
+	if method == "" {
+		switch {
+		case os.Getenv("VAULT_TOKEN") != "":
+			method = vaultAuthToken
+		case os.Getenv("VAULT_ROLE_ID") != "":
+			method = vaultAuthAppRole
+		case os.Getenv("VAULT_AWS_ROLE") != "":
+			method = vaultAuthAWS
+		default:
+			return "", fmt.Errorf("no vault credentials configured")
+		}
+	}
+
+	var body map[string]string
+	var err error
+	switch method {
+	case vaultAuthToken:
+		v.token = os.Getenv("VAULT_TOKEN")
+		return v.token, nil
+	case vaultAuthAppRole:
+		body = map[string]string{
+			"role_id":   os.Getenv("VAULT_ROLE_ID"),
+			"secret_id": os.Getenv("VAULT_SECRET_ID"),
+		}
+	case vaultAuthAWS:
+		body, err = awsLoginData(ctx, v.credentials)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported vault auth method '%s'", method)
+	}
+
+	mount := os.Getenv("VAULT_AUTH_MOUNT")
+	if mount == "" {
+		mount = method
+	}
+
+	var response vaultLoginResponse
+	err = v.do(ctx, http.MethodPost, fmt.Sprintf("/v1/auth/%s/login", mount), "", body, &response)
+	if err != nil {
+		return "", err
+	}
+	if response.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault returned no client token")
+	}
+
+	v.token = response.Auth.ClientToken
+	return v.token, nil
+}
+
+func (v *vaultProvider) do(ctx context.Context, method string, path string, token string, body interface{}, result interface{}) error {
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, addr+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := v.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s %s returned %s", method, path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// awsLoginData builds the signed sts:GetCallerIdentity request Vault's AWS
+// IAM auth method verifies on our behalf.
+func awsLoginData(ctx context.Context, provider aws.CredentialsProvider) (map[string]string, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("no AWS credentials configured")
+	}
+
+	credentials, err := provider.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stsURL := "https://sts.amazonaws.com/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsURL, strings.NewReader(stsRequestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if serverID := os.Getenv("VAULT_AWS_HEADER_VALUE"); serverID != "" {
+		req.Header.Set("X-Vault-AWS-IAM-Server-ID", serverID)
+	}
+
+	payloadHash := sha256.Sum256([]byte(stsRequestBody))
+	err = v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "sts", "us-east-1", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"role":                    os.Getenv("VAULT_AWS_ROLE"),
+		"iam_http_request_method": http.MethodPost,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(stsURL)),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(stsRequestBody)),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+	}, nil
+}
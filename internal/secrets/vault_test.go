@@ -0,0 +1,223 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault serves KV v2 reads and the AppRole and AWS login endpoints.
+type fakeVault struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	logins   []map[string]string
+	requests []string
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	t.Helper()
+
+	v := &fakeVault{}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.handle))
+	t.Cleanup(v.Close)
+
+	for _, name := range []string{"VAULT_TOKEN", "VAULT_ROLE_ID", "VAULT_SECRET_ID", "VAULT_AWS_ROLE", "VAULT_AUTH_METHOD", "VAULT_AUTH_MOUNT", "VAULT_NAMESPACE", "VAULT_AWS_HEADER_VALUE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("VAULT_ADDR", v.URL+"/")
+	return v
+}
+
+func (v *fakeVault) handle(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	v.requests = append(v.requests, r.Method+" "+r.URL.RequestURI()+" ns="+r.Header.Get("X-Vault-Namespace"))
+	v.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/auth/"):
+		body := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		v.mu.Lock()
+		v.logins = append(v.logins, body)
+		v.mu.Unlock()
+
+		if body["secret_id"] == "wrong" {
+			http.Error(w, `{"errors":["invalid secret id"]}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]string{"client_token": "s.login"}})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/renovate/github-app":
+		if token := r.Header.Get("X-Vault-Token"); token != "s.root" && token != "s.login" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		data := map[string]interface{}{"pem": "-----BEGIN-----", "app_id": 12345}
+		if r.URL.Query().Get("version") == "1" {
+			data = map[string]interface{}{"pem": "old"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "vault://secret/renovate/github-app?key=pem", want: "-----BEGIN-----"},
+		{uri: "vault://secret/renovate/github-app?key=app_id", want: "12345"},
+		{uri: "vault://secret/renovate/github-app", want: `{"app_id":12345,"pem":"-----BEGIN-----"}`},
+		{uri: "vault://secret/renovate/github-app?version=1", want: "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			vault := newFakeVault(t)
+			t.Setenv("VAULT_TOKEN", "s.root")
+			t.Setenv("VAULT_NAMESPACE", "platform")
+			providers := NewProviders(nil, nil, vault.Client(), nil)
+
+			got, err := providers.GetSecret(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GetSecret = %q, want %q", got, tt.want)
+			}
+			if len(vault.requests) != 1 || !strings.HasSuffix(vault.requests[0], "ns=platform") {
+				t.Errorf("requests = %q, want one read in namespace platform", vault.requests)
+			}
+		})
+	}
+}
+
+func TestVaultAppRoleLogin(t *testing.T) {
+	vault := newFakeVault(t)
+	t.Setenv("VAULT_ROLE_ID", "role")
+	t.Setenv("VAULT_SECRET_ID", "secret")
+	t.Setenv("VAULT_AUTH_MOUNT", "renovate-approle")
+
+	provider := NewVaultProvider(vault.Client(), nil)
+	ref, _ := ParseReference("vault://secret/renovate/github-app?key=pem")
+	for i := 0; i < 2; i++ {
+		got, err := provider.GetSecret(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "-----BEGIN-----" {
+			t.Errorf("GetSecret = %q", got)
+		}
+	}
+
+	if len(vault.logins) != 1 {
+		t.Fatalf("logged in %d times, want the token to be reused", len(vault.logins))
+	}
+	if vault.logins[0]["role_id"] != "role" || vault.logins[0]["secret_id"] != "secret" {
+		t.Errorf("login body = %v", vault.logins[0])
+	}
+	if !strings.HasPrefix(vault.requests[0], "POST /v1/auth/renovate-approle/login") {
+		t.Errorf("first request = %q, want the AppRole login", vault.requests[0])
+	}
+}
+
+func TestVaultAWSLogin(t *testing.T) {
+	vault := newFakeVault(t)
+	t.Setenv("VAULT_AWS_ROLE", "renovate")
+	t.Setenv("VAULT_AWS_HEADER_VALUE", "vault.example.com")
+
+	credentials := credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "")
+	provider := NewVaultProvider(vault.Client(), credentials)
+	ref, _ := ParseReference("vault://secret/renovate/github-app?key=pem")
+	if _, err := provider.GetSecret(context.Background(), ref); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vault.logins) != 1 {
+		t.Fatalf("logged in %d times, want 1", len(vault.logins))
+	}
+	login := vault.logins[0]
+	if login["role"] != "renovate" || login["iam_http_request_method"] != http.MethodPost {
+		t.Errorf("login body = %v", login)
+	}
+
+	encodedHeaders, err := base64.StdEncoding.DecodeString(login["iam_request_headers"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := http.Header{}
+	if err := json.Unmarshal(encodedHeaders, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if auth := headers.Get("Authorization"); !strings.Contains(auth, "Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/sts/aws4_request") {
+		t.Errorf("Authorization = %q, want a SigV4 sts signature", auth)
+	}
+	if headers.Get("X-Vault-Aws-Iam-Server-Id") != "vault.example.com" {
+		t.Errorf("server id header = %q", headers.Get("X-Vault-Aws-Iam-Server-Id"))
+	}
+	if !strings.HasPrefix(vault.requests[0], "POST /v1/auth/aws/login") {
+		t.Errorf("first request = %q, want the AWS login", vault.requests[0])
+	}
+}
+
+func TestVaultProviderErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		uri         string
+		credentials aws.CredentialsProvider
+		err         string
+	}{
+		{name: "invalid reference", env: map[string]string{"VAULT_TOKEN": "s.root"}, uri: "vault://secret", err: "expected vault://<mount>/<path>"},
+		{name: "no credentials", uri: "vault://secret/renovate/github-app", err: "no vault credentials configured"},
+		{name: "unknown method", env: map[string]string{"VAULT_AUTH_METHOD": "ldap"}, uri: "vault://secret/renovate/github-app", err: "unsupported vault auth method 'ldap'"},
+		{name: "aws without credentials", env: map[string]string{"VAULT_AWS_ROLE": "renovate"}, uri: "vault://secret/renovate/github-app", err: "no AWS credentials configured"},
+		{name: "login rejected", env: map[string]string{"VAULT_ROLE_ID": "role", "VAULT_SECRET_ID": "wrong"}, uri: "vault://secret/renovate/github-app", err: "400 Bad Request"},
+		{name: "permission denied", env: map[string]string{"VAULT_TOKEN": "s.other"}, uri: "vault://secret/renovate/github-app", err: "403 Forbidden"},
+		{name: "missing key", env: map[string]string{"VAULT_TOKEN": "s.root"}, uri: "vault://secret/renovate/github-app?key=token", err: "has no key 'token'"},
+		{name: "missing secret", env: map[string]string{"VAULT_TOKEN": "s.root"}, uri: "vault://secret/renovate/missing", err: "404 Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := newFakeVault(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			ref, err := ParseReference(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = NewVaultProvider(vault.Client(), tt.credentials).GetSecret(context.Background(), ref)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("GetSecret error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVaultWithoutAddress(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_AUTH_METHOD", "")
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE_ID", "role")
+
+	ref, _ := ParseReference("vault://secret/renovate/github-app")
+	_, err := NewVaultProvider(nil, nil).GetSecret(context.Background(), ref)
+	if err == nil || !strings.Contains(err.Error(), "VAULT_ADDR is not set") {
+		t.Errorf("GetSecret error = %v, want VAULT_ADDR is not set", err)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"strings"
+)
+
+const githubAPIHost = "api.github.com"
+
+type BotIdentity struct {
+	Slug  string
+	Login string
+	ID    int64
+	Email string
+}
+
+func (b *BotIdentity) GitAuthor() string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s <%s>", b.Login, b.Email)
+}
+
+// ResolveBotIdentity looks up the "<slug>[bot]" user of the GitHub App. The
+// app client (authenticated with the JWT) reads the app slug, the user lookup
+// needs an installation client because the users API rejects app JWTs.
+func ResolveBotIdentity(appClient *github.Client, installationClient *github.Client) (*BotIdentity, error) {
+	app, _, err := appClient.Apps.Get(context.Background(), "")
+	if err != nil {
+		return nil, fmt.Errorf("error reading application: %v", err)
+	}
+
+	login := fmt.Sprintf("%s[bot]", app.GetSlug())
+	user, _, err := installationClient.Users.Get(context.Background(), login)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bot user %s: %v", login, err)
+	}
+
+	return &BotIdentity{
+		Slug:  app.GetSlug(),
+		Login: login,
+		ID:    user.GetID(),
+		Email: fmt.Sprintf("%d+%s@users.noreply.%s", user.GetID(), login, noreplyHost(appClient)),
+	}, nil
+}
+
+// noreplyHost returns the web host used for noreply addresses: github.com
+// and GHE.com data residency APIs live on "api.<host>", GHES serves its API
+// from "<host>/api/v3".
+func noreplyHost(client *github.Client) string {
+	host := strings.ToLower(client.BaseURL.Hostname())
+	return strings.TrimPrefix(host, "api.")
+}
+
+func IsEnterprise(client *github.Client) bool {
+	return !strings.EqualFold(client.BaseURL.Hostname(), githubAPIHost)
+}
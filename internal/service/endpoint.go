@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	githubWebHost   = "github.com"
+	gheDotComSuffix = ".ghe.com"
+)
+
+// ParseEndpoint turns the configured endpoint into the REST API base URL.
+//
+//   - "" and "github.com" resolve to https://api.github.com/.
+//   - GHE.com data residency hosts ("<sub>.ghe.com") resolve to
+//     https://api.<sub>.ghe.com/.
+//   - A bare host, optionally with a port, is a GHES instance served from
+//     https://<host>/api/v3/.
+//   - A full URL keeps its scheme, port and path. Without a path the API is
+//     assumed at /api/v3/ unless the host is an "api." host, an explicit "/"
+//     selects the root. The "api." rule does not apply to bare hosts.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		endpoint = githubAPIHost
+	}
+
+	raw := endpoint
+	bareHost := !strings.Contains(raw, "://")
+	if bareHost {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint '%s': %v", endpoint, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint '%s': missing host", endpoint)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint '%s': unsupported scheme '%s'", endpoint, u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == githubWebHost || host == githubAPIHost:
+		u.Host = githubAPIHost
+		u.Path = "/"
+	case strings.HasSuffix(host, gheDotComSuffix):
+		if !strings.HasPrefix(host, "api.") {
+			u.Host = "api." + u.Host
+		}
+		u.Path = "/"
+	case u.Path == "" && !bareHost && strings.HasPrefix(host, "api."):
+		u.Path = "/"
+	case u.Path == "":
+		u.Path = "/api/v3/"
+	}
+
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u, nil
+}
+
+// uploadURL returns the uploads API matching a REST API base URL.
+func uploadURL(baseURL *url.URL) *url.URL {
+	upload := *baseURL
+	switch {
+	case strings.EqualFold(baseURL.Host, githubAPIHost):
+		upload.Host = "uploads.github.com"
+	case strings.HasSuffix(baseURL.Path, "/api/v3/"):
+		upload.Path = strings.TrimSuffix(baseURL.Path, "/api/v3/") + "/api/uploads/"
+	}
+	return &upload
+}
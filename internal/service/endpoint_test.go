@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		base     string
+		upload   string
+	}{
+		{endpoint: "", base: "https://api.github.com/", upload: "https://uploads.github.com/"},
+		{endpoint: "github.com", base: "https://api.github.com/", upload: "https://uploads.github.com/"},
+		{endpoint: "https://api.github.com", base: "https://api.github.com/", upload: "https://uploads.github.com/"},
+		{endpoint: "ghe.corp", base: "https://ghe.corp/api/v3/", upload: "https://ghe.corp/api/uploads/"},
+		{endpoint: "ghe.corp:8443", base: "https://ghe.corp:8443/api/v3/", upload: "https://ghe.corp:8443/api/uploads/"},
+		{endpoint: "api.ghe.corp", base: "https://api.ghe.corp/api/v3/", upload: "https://api.ghe.corp/api/uploads/"},
+		{endpoint: " ghe.corp ", base: "https://ghe.corp/api/v3/", upload: "https://ghe.corp/api/uploads/"},
+		{endpoint: "https://ghe.corp", base: "https://ghe.corp/api/v3/", upload: "https://ghe.corp/api/uploads/"},
+		{endpoint: "https://ghe.corp/api/v3", base: "https://ghe.corp/api/v3/", upload: "https://ghe.corp/api/uploads/"},
+		{endpoint: "https://ghe.corp/github/api/v3/?x=1#top", base: "https://ghe.corp/github/api/v3/", upload: "https://ghe.corp/github/api/uploads/"},
+		{endpoint: "https://ghe.corp/", base: "https://ghe.corp/", upload: "https://ghe.corp/"},
+		{endpoint: "https://api.ghe.corp", base: "https://api.ghe.corp/", upload: "https://api.ghe.corp/"},
+		{endpoint: "http://localhost", base: "http://localhost/api/v3/", upload: "http://localhost/api/uploads/"},
+		{endpoint: "http://localhost:8080/", base: "http://localhost:8080/", upload: "http://localhost:8080/"},
+		{endpoint: "octo.ghe.com", base: "https://api.octo.ghe.com/", upload: "https://api.octo.ghe.com/"},
+		{endpoint: "https://api.octo.ghe.com", base: "https://api.octo.ghe.com/", upload: "https://api.octo.ghe.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			base, err := ParseEndpoint(tt.endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if base.String() != tt.base {
+				t.Errorf("ParseEndpoint(%q) = %s, want %s", tt.endpoint, base, tt.base)
+			}
+			if upload := uploadURL(base); upload.String() != tt.upload {
+				t.Errorf("uploadURL(%s) = %s, want %s", base, upload, tt.upload)
+			}
+		})
+	}
+}
+
+func TestParseEndpointErrors(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		err      string
+	}{
+		{endpoint: "https://", err: "missing host"},
+		{endpoint: "ftp://ghe.corp", err: "unsupported scheme 'ftp'"},
+		{endpoint: "https://ghe corp", err: "invalid endpoint"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseEndpoint(tt.endpoint)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseEndpoint(%q) error = %v, want it to contain %q", tt.endpoint, err, tt.err)
+		}
+	}
+}
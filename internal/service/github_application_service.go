@@ -2,22 +2,45 @@ package service
 
 import (
 	"context"
-	"crypto/rsa"
-	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v63/github"
 	"golang.org/x/oauth2"
 	"log"
-	"net/url"
+	"net/http"
 	"time"
 )
 
 type enumerateFunc func(*github.Installation, *github.Repository)
-type processFunc func([]string, string, string)
+type processFunc func(InstallationContext) error
+
+// InstallationContext is what ProcessInstallationRepository hands to its
+// processor: the installation's repositories, token, platform details and a
+// client authenticated as the installation.
+type InstallationContext struct {
+	Repositories []RepositoryMetadata
+	Token        string
+	Platform     string
+	Endpoint     string
+	IsEnterprise bool
+	Bot          *BotIdentity
+	Client       *github.Client
+}
 
 type RenovateGitHubApplicationService interface {
-	EnumerateInstallationRepositories(processor enumerateFunc)
-	ProcessInstallationRepository(installationId int64, processor processFunc) error
+	EnumerateInstallationRepositories(processor enumerateFunc) (*EnumerationSummary, error)
+	ProcessInstallationRepository(installationId int64, tokenOptions *github.InstallationTokenOptions, processor processFunc) error
+}
+
+type InstallationFailure struct {
+	InstallationID int64
+	Account        string
+	Err            error
+}
+
+type EnumerationSummary struct {
+	Installations int
+	Repositories  int
+	Failures      []InstallationFailure
 }
 
 type ApplicationService struct {
@@ -31,70 +54,88 @@ func NewRenovateGitHubApplicationService(client *github.Client) *ApplicationServ
 	}
 }
 
-func (a *ApplicationService) EnumerateInstallationRepositories(processor enumerateFunc) error {
-	opts := &github.ListOptions{PerPage: 10}
+func (a *ApplicationService) EnumerateInstallationRepositories(processor enumerateFunc) (*EnumerationSummary, error) {
+	summary := &EnumerationSummary{}
+
+	opts := &github.ListOptions{PerPage: 100}
 	for {
 		installations, resp, err := a.Client.Apps.ListInstallations(context.Background(), opts)
 
 		if err != nil {
-			return err
+			return summary, err
 		}
 
 		for _, installation := range installations {
-			token, _, err := a.Client.Apps.CreateInstallationToken(context.Background(), installation.GetID(), nil)
+			summary.Installations++
+
+			count, err := a.enumerateInstallation(installation, processor)
+			summary.Repositories += count
 			if err != nil {
-				return err
+				failure := InstallationFailure{
+					InstallationID: installation.GetID(),
+					Account:        installation.GetAccount().GetLogin(),
+					Err:            err,
+				}
+				log.Printf("Skipping installation %d (%s): %v", failure.InstallationID, failure.Account, err)
+				summary.Failures = append(summary.Failures, failure)
 			}
+		}
 
-			log.Printf("Processing repositories for installation %d", installation.GetID())
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
 
-			installationToken := token.GetToken()
-			installationClient, _ := CreateClient(installationToken, a.Client.BaseURL.Host)
+	return summary, nil
+}
 
-			repoOpts := &github.ListOptions{PerPage: 10}
-			for {
-				repos, repoResp, err := installationClient.Apps.ListRepos(context.Background(), repoOpts)
-				if err != nil {
-					return err
-				}
+func (a *ApplicationService) enumerateInstallation(installation *github.Installation, processor enumerateFunc) (int, error) {
+	log.Printf("Processing repositories for installation %d (%s)", installation.GetID(), installation.GetAccount().GetLogin())
 
-				for _, repo := range repos.Repositories {
-					processor(installation, repo)
-				}
+	ts := NewInstallationTokenSource(a.Client, installation.GetID(), nil)
+	installationClient := DeriveClient(a.Client, ts)
 
-				if repoResp.NextPage == 0 {
-					break
-				}
-				repoOpts.Page = repoResp.NextPage
-			}
+	count := 0
+	repoOpts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, repoResp, err := installationClient.Apps.ListRepos(context.Background(), repoOpts)
+		if err != nil {
+			return count, err
 		}
 
-		if resp.NextPage == 0 {
+		for _, repo := range repos.Repositories {
+			processor(installation, repo)
+			count++
+		}
+
+		if repoResp.NextPage == 0 {
 			break
 		}
-		opts.Page = resp.NextPage
+		repoOpts.Page = repoResp.NextPage
 	}
 
-	return nil
+	return count, nil
 }
 
-func (a *ApplicationService) ProcessInstallationRepository(installationId int64, processor processFunc) error {
+func (a *ApplicationService) ProcessInstallationRepository(installationId int64, tokenOptions *github.InstallationTokenOptions, processor processFunc) error {
 	installation, _, err := a.Client.Apps.GetInstallation(context.Background(), installationId)
 
 	if err != nil {
 		return err
 	}
 
-	token, _, err := a.Client.Apps.CreateInstallationToken(context.Background(), installation.GetID(), nil)
+	ts := NewInstallationTokenSource(a.Client, installation.GetID(), tokenOptions)
+	token, err := ts.Token()
 	if err != nil {
 		return err
 	}
 
-	installationToken := token.GetToken()
-	installationClient, _ := CreateClient(installationToken, a.Client.BaseURL.Host)
+	installationToken := token.AccessToken
+	installationClient := DeriveClient(a.Client, ts)
 
-	var repoList []string
-	repoOpts := &github.ListOptions{PerPage: 10}
+	var repoList []RepositoryMetadata
+	repoOpts := &github.ListOptions{PerPage: 100}
 	for {
 		repos, repoResp, err := installationClient.Apps.ListRepos(context.Background(), repoOpts)
 		if err != nil {
@@ -102,7 +143,7 @@ func (a *ApplicationService) ProcessInstallationRepository(installationId int64,
 		}
 
 		for _, repo := range repos.Repositories {
-			repoList = append(repoList, repo.GetFullName())
+			repoList = append(repoList, NewRepositoryMetadata(repo))
 		}
 
 		if repoResp.NextPage == 0 {
@@ -111,49 +152,92 @@ func (a *ApplicationService) ProcessInstallationRepository(installationId int64,
 		repoOpts.Page = repoResp.NextPage
 	}
 
-	endpoint := fmt.Sprintf("%s://%s%s", a.Client.BaseURL.Scheme, a.Client.BaseURL.Host, a.Client.BaseURL.Path)
-	processor(repoList, installationToken, endpoint)
+	bot, err := ResolveBotIdentity(a.Client, installationClient)
+	if err != nil {
+		log.Printf("Unable to resolve application bot identity: %v", err)
+	}
 
-	return nil
+	return processor(InstallationContext{
+		Repositories: repoList,
+		Token:        installationToken,
+		Platform:     "github",
+		Endpoint:     a.Client.BaseURL.String(),
+		IsEnterprise: IsEnterprise(a.Client),
+		Bot:          bot,
+		Client:       installationClient,
+	})
 }
 
-func CreateClient(token string, endpoint string) (*github.Client, error) {
+func CreateClient(token string, endpoint string, httpClient *http.Client) (*github.Client, error) {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(context.Background(), ts)
+	return CreateClientWithTokenSource(ts, endpoint, httpClient)
+}
 
-	var client *github.Client
-	if endpoint == "" || endpoint == "api.github.com" {
-		client = github.NewClient(tc)
-	} else {
-		endpointUrl, err := url.Parse(fmt.Sprintf("https://%s/api/v3/", endpoint))
-		if err != nil {
-			return nil, err
-		}
+// CreateClientWithTokenSource returns a client for endpoint authenticated by
+// ts. httpClient supplies the transport requests are sent with; nil uses
+// http.DefaultTransport.
+func CreateClientWithTokenSource(ts oauth2.TokenSource, endpoint string, httpClient *http.Client) (*github.Client, error) {
+	var base http.RoundTripper
+	if httpClient != nil {
+		base = httpClient.Transport
+	}
+
+	tc := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: ts,
+			Base:   NewRateLimitTransport(base),
+		},
+	}
 
-		client = github.NewClient(tc)
-		client.BaseURL = endpointUrl
-		client.UploadURL = endpointUrl
+	endpointUrl, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
 	}
 
+	client := github.NewClient(tc)
+	client.BaseURL = endpointUrl
+	client.UploadURL = uploadURL(endpointUrl)
+
 	return client, nil
 }
 
-func GenerateJWT(applicationID string, privateKey *rsa.PrivateKey) (string, error) {
+// DeriveClient returns a client for the endpoint of client authenticated by
+// ts instead. It shares the transport below the authentication of client, so
+// installation clients keep the proxy, TLS and rate limit handling of the app
+// client.
+func DeriveClient(client *github.Client, ts oauth2.TokenSource) *github.Client {
+	base := client.Client().Transport
+	if transport, ok := base.(*oauth2.Transport); ok {
+		base = transport.Base
+	}
+
+	derived := github.NewClient(&http.Client{
+		Transport: &oauth2.Transport{
+			Source: ts,
+			Base:   base,
+		},
+	})
+	derived.BaseURL = client.BaseURL
+	derived.UploadURL = client.UploadURL
+	return derived
+}
+
+func generateJWT(applicationID string, signer Signer, now time.Time) (string, time.Time, error) {
+	expiry := now.Add(jwtLifetime)
+
 	// Create the claims
 	claims := jwt.MapClaims{
-		"iat": time.Now().Unix(),                      // Issued at time
-		"exp": time.Now().Add(time.Minute * 5).Unix(), // Expiration time (10 minutes from now)
-		"iss": applicationID,                          // GitHub App ID
+		"iat": now.Add(-jwtClockSkew).Unix(), // Issued at time, backdated to allow for clock drift
+		"exp": expiry.Unix(),                 // Expiration time (GitHub allows at most 10 minutes)
+		"iss": applicationID,                 // GitHub App ID
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-
-	tokenString, err := token.SignedString(privateKey)
+	tokenString, err := signJWT(signer, claims)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, expiry, nil
 }
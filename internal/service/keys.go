@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"log"
+	"net/http"
+)
+
+var ErrNoValidKey = errors.New("none of the configured keys was accepted by GitHub")
+
+// CheckSigner verifies that GitHub accepts JWTs signed by signer by reading
+// the authenticated app.
+func CheckSigner(applicationID string, endpoint string, signer Signer, httpClient *http.Client) (*github.Client, *github.App, error) {
+	ts := NewApplicationTokenSourceWithSigner(applicationID, signer)
+	client, err := CreateClientWithTokenSource(ts, endpoint, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	app, _, err := client.Apps.Get(context.Background(), "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, app, nil
+}
+
+// SelectSigner returns an app client for the first signer GitHub accepts.
+// Signers rejected with 401 Unauthorized are skipped, which allows the old
+// and new key to be configured while a key rotation is in progress.
+func SelectSigner(applicationID string, endpoint string, signers []Signer, httpClient *http.Client) (*github.Client, error) {
+	for _, signer := range signers {
+		client, app, err := CheckSigner(applicationID, endpoint, signer, httpClient)
+		if err == nil {
+			log.Printf("Authenticated as application %s using key %s", app.GetSlug(), signer.ID())
+			return client, nil
+		}
+		if !IsUnauthorized(err) {
+			return nil, fmt.Errorf("error authenticating with key %s: %v", signer.ID(), err)
+		}
+		log.Printf("Key %s was rejected by GitHub, trying next key", signer.ID())
+	}
+
+	return nil, ErrNoValidKey
+}
+
+func IsUnauthorized(err error) bool {
+	var errorResponse *github.ErrorResponse
+	return errors.As(err, &errorResponse) &&
+		errorResponse.Response != nil &&
+		errorResponse.Response.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"strings"
+)
+
+const DefaultTokenPermissions = "contents:write,pull_requests:write,issues:write,workflows:write"
+
+// ParseInstallationPermissions parses a comma separated list of
+// "<permission>:<access>" pairs, e.g. "contents:write,metadata:read".
+// A permission without an access level is granted write access.
+func ParseInstallationPermissions(spec string) (*github.InstallationPermissions, error) {
+	permissions := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, access, found := strings.Cut(entry, ":")
+		if !found {
+			access = "write"
+		}
+		name = strings.ReplaceAll(strings.TrimSpace(name), "-", "_")
+		access = strings.TrimSpace(access)
+
+		if access != "read" && access != "write" && access != "admin" {
+			return nil, fmt.Errorf("invalid access level %q for permission %q", access, name)
+		}
+		permissions[name] = access
+	}
+
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+
+	var result github.InstallationPermissions
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid installation permissions %q: %v", spec, err)
+	}
+
+	return &result, nil
+}
+
+// ScopedTokenOptions restricts an installation token to a single
+// "owner/name" repository with the given permissions.
+func ScopedTokenOptions(repository string, permissions *github.InstallationPermissions) *github.InstallationTokenOptions {
+	name := repository
+	if _, after, found := strings.Cut(repository, "/"); found {
+		name = after
+	}
+
+	return &github.InstallationTokenOptions{
+		Repositories: []string{name},
+		Permissions:  permissions,
+	}
+}
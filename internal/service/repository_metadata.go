@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"github.com/google/go-github/v63/github"
+	"log"
+)
+
+type RepositoryMetadata struct {
+	FullName         string
+	Owner            string
+	OwnerType        string
+	Name             string
+	DefaultBranch    string
+	Topics           []string
+	Language         string
+	Visibility       string
+	Private          bool
+	Archived         bool
+	Fork             bool
+	CustomProperties map[string]string
+}
+
+func NewRepositoryMetadata(repo *github.Repository) RepositoryMetadata {
+	return RepositoryMetadata{
+		FullName:         repo.GetFullName(),
+		Owner:            repo.GetOwner().GetLogin(),
+		OwnerType:        repo.GetOwner().GetType(),
+		Name:             repo.GetName(),
+		DefaultBranch:    repo.GetDefaultBranch(),
+		Topics:           repo.Topics,
+		Language:         repo.GetLanguage(),
+		Visibility:       repo.GetVisibility(),
+		Private:          repo.GetPrivate(),
+		Archived:         repo.GetArchived(),
+		Fork:             repo.GetFork(),
+		CustomProperties: map[string]string{},
+	}
+}
+
+// LoadCustomProperties reads the custom properties of one repository. It costs
+// an API call per repository, so callers only load them for the repository
+// they render config for.
+func LoadCustomProperties(client *github.Client, metadata *RepositoryMetadata) {
+	// Custom properties only exist on organization owned repositories.
+	if metadata.OwnerType != "Organization" {
+		return
+	}
+
+	values, _, err := client.Repositories.GetAllCustomPropertyValues(context.Background(), metadata.Owner, metadata.Name)
+	if err != nil {
+		log.Printf("Unable to read custom properties for %s: %v", metadata.FullName, err)
+		return
+	}
+
+	for _, value := range values {
+		metadata.CustomProperties[value.PropertyName] = value.GetValue()
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer produces an RSASSA-PKCS1-v1_5 signature over a SHA-256 digest, which
+// is what an RS256 JWT needs.
+type Signer interface {
+	Sign(ctx context.Context, digest []byte) ([]byte, error)
+	ID() string
+}
+
+type RSASigner struct {
+	PrivateKey *rsa.PrivateKey
+}
+
+// ID returns the SHA-256 fingerprint GitHub shows for the app's private keys.
+func (s RSASigner) ID() string {
+	der, err := x509.MarshalPKIXPublicKey(&s.PrivateKey.PublicKey)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (s RSASigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	return rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, digest)
+}
+
+type KMSAPI interface {
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+// KMSSigner signs with an asymmetric RSA KMS key so the private key never
+// leaves KMS.
+type KMSSigner struct {
+	KeyID  string
+	Client KMSAPI
+}
+
+func NewKMSSigner(keyID string, client KMSAPI) *KMSSigner {
+	return &KMSSigner{
+		KeyID:  keyID,
+		Client: client,
+	}
+}
+
+func (s *KMSSigner) ID() string {
+	return "kms:" + s.KeyID
+}
+
+func (s *KMSSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	output, err := s.Client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.KeyID),
+		Message:          digest,
+		MessageType:      kmstypes.MessageTypeDigest,
+		SigningAlgorithm: kmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms sign failed: %v", err)
+	}
+	return output.Signature, nil
+}
+
+func signJWT(signer Signer, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+
+	signingString, err := token.SigningString()
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256([]byte(signingString))
+	signature, err := signer.Sign(context.Background(), digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
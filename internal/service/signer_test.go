@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeKMS signs digests with a local RSA key the way an asymmetric
+// RSA_2048 KMS key would.
+type fakeKMS struct {
+	key   *rsa.PrivateKey
+	err   error
+	input *kms.SignInput
+}
+
+func (f *fakeKMS) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	f.input = params
+	if f.err != nil {
+		return nil, f.err
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, params.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: params.KeyId, Signature: signature}, nil
+}
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKMSSignerJWT(t *testing.T) {
+	key := generateTestKey(t)
+	client := &fakeKMS{key: key}
+	signer := NewKMSSigner("alias/renovate-controller", client)
+
+	tokenString, expiry, err := generateJWT("12345", signer, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		t.Fatalf("JWT signed by KMS does not verify: %v", err)
+	}
+
+	issuer, _ := token.Claims.GetIssuer()
+	if issuer != "12345" {
+		t.Errorf("iss = %q, want 12345", issuer)
+	}
+	exp, _ := token.Claims.GetExpirationTime()
+	if exp == nil || exp.Unix() != expiry.Unix() {
+		t.Errorf("exp = %v, want %s", exp, expiry)
+	}
+
+	if aws.ToString(client.input.KeyId) != "alias/renovate-controller" {
+		t.Errorf("KeyId = %q", aws.ToString(client.input.KeyId))
+	}
+	if client.input.MessageType != kmstypes.MessageTypeDigest {
+		t.Errorf("MessageType = %q, want DIGEST", client.input.MessageType)
+	}
+	if client.input.SigningAlgorithm != kmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha256 {
+		t.Errorf("SigningAlgorithm = %q", client.input.SigningAlgorithm)
+	}
+	if signer.ID() != "kms:alias/renovate-controller" {
+		t.Errorf("ID = %q", signer.ID())
+	}
+}
+
+func TestKMSSignerWrongKey(t *testing.T) {
+	signer := NewKMSSigner("alias/renovate-controller", &fakeKMS{key: generateTestKey(t)})
+
+	tokenString, _, err := generateJWT("12345", signer, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := generateTestKey(t)
+	_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return &other.PublicKey, nil
+	})
+	if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Errorf("Parse error = %v, want an invalid signature", err)
+	}
+}
+
+func TestKMSSignerError(t *testing.T) {
+	signer := NewKMSSigner("alias/renovate-controller", &fakeKMS{err: errors.New("AccessDeniedException")})
+
+	_, _, err := generateJWT("12345", signer, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "kms sign failed: AccessDeniedException") {
+		t.Errorf("generateJWT error = %v, want the KMS error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"golang.org/x/oauth2"
+	"sync"
+	"time"
+)
+
+const (
+	jwtLifetime          = 9 * time.Minute
+	jwtClockSkew         = 60 * time.Second
+	jwtRefreshMargin     = time.Minute
+	installationMargin   = 5 * time.Minute
+	installationFallback = 55 * time.Minute
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ApplicationTokenSource signs a new GitHub App JWT shortly before the
+// previous one expires.
+type ApplicationTokenSource struct {
+	ApplicationID string
+	Signer        Signer
+	Clock         Clock
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func NewApplicationTokenSourceWithSigner(applicationID string, signer Signer) *ApplicationTokenSource {
+	return &ApplicationTokenSource{
+		ApplicationID: applicationID,
+		Signer:        signer,
+		Clock:         systemClock{},
+	}
+}
+
+func (s *ApplicationTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clockNow(s.Clock)
+	if s.token != nil && now.Add(jwtRefreshMargin).Before(s.token.Expiry) {
+		return s.token, nil
+	}
+
+	tokenString, expiry, err := generateJWT(s.ApplicationID, s.Signer, now)
+	if err != nil {
+		return nil, fmt.Errorf("error generating JWT: %v", err)
+	}
+
+	s.token = &oauth2.Token{
+		AccessToken: tokenString,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}
+	return s.token, nil
+}
+
+// InstallationTokenSource caches an installation access token until shortly
+// before its expires_at and mints a new one through the app client.
+type InstallationTokenSource struct {
+	Client         *github.Client
+	InstallationID int64
+	Options        *github.InstallationTokenOptions
+	Clock          Clock
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func NewInstallationTokenSource(client *github.Client, installationID int64, opts *github.InstallationTokenOptions) *InstallationTokenSource {
+	return &InstallationTokenSource{
+		Client:         client,
+		InstallationID: installationID,
+		Options:        opts,
+		Clock:          systemClock{},
+	}
+}
+
+func (s *InstallationTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clockNow(s.Clock)
+	if s.token != nil && now.Add(installationMargin).Before(s.token.Expiry) {
+		return s.token, nil
+	}
+
+	token, _, err := s.Client.Apps.CreateInstallationToken(context.Background(), s.InstallationID, s.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := token.GetExpiresAt().Time
+	if expiry.IsZero() {
+		expiry = now.Add(installationFallback)
+	}
+
+	s.token = &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}
+	return s.token, nil
+}
+
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
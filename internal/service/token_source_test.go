@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type countingSigner struct {
+	calls int
+}
+
+func (s *countingSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	s.calls++
+	return []byte("signature"), nil
+}
+
+func (s *countingSigner) ID() string {
+	return "counting"
+}
+
+func TestApplicationTokenSource(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	signer := &countingSigner{}
+
+	ts := NewApplicationTokenSourceWithSigner("12345", signer)
+	ts.Clock = clock
+
+	first, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(jwtLifetime); !first.Expiry.Equal(want) {
+		t.Errorf("expiry = %s, want %s", first.Expiry, want)
+	}
+	if first.TokenType != "Bearer" {
+		t.Errorf("token type = %q, want Bearer", first.TokenType)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		signs   int
+	}{
+		{advance: time.Minute, signs: 1},
+		{advance: jwtLifetime - jwtRefreshMargin - time.Minute - time.Second, signs: 1},
+		{advance: time.Second, signs: 2},
+		{advance: jwtLifetime, signs: 3},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		if _, err := ts.Token(); err != nil {
+			t.Fatal(err)
+		}
+		if signer.calls != step.signs {
+			t.Fatalf("after %s: signed %d times, want %d", clock.Now().Sub(first.Expiry.Add(-jwtLifetime)), signer.calls, step.signs)
+		}
+	}
+}
+
+func TestGenerateJWTClaims(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tokenString, expiry, err := generateJWT("12345", &countingSigner{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiry.Equal(now.Add(jwtLifetime)) {
+		t.Errorf("expiry = %s, want %s", expiry, now.Add(jwtLifetime))
+	}
+
+	claims := decodeClaims(t, tokenString)
+	if claims["iss"] != "12345" {
+		t.Errorf("iss = %v, want 12345", claims["iss"])
+	}
+	if iat := int64(claims["iat"].(float64)); iat != now.Add(-jwtClockSkew).Unix() {
+		t.Errorf("iat = %d, want %d", iat, now.Add(-jwtClockSkew).Unix())
+	}
+	if exp := int64(claims["exp"].(float64)); exp != expiry.Unix() {
+		t.Errorf("exp = %d, want %d", exp, expiry.Unix())
+	}
+}
+
+// newInstallationTokenServer issues tokens that expire after lifetime; a zero
+// lifetime omits expires_at.
+func newInstallationTokenServer(t *testing.T, clock *fakeClock, lifetime time.Duration) (*github.Client, *int) {
+	t.Helper()
+
+	minted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+		minted++
+
+		response := map[string]interface{}{"token": fmt.Sprintf("ghs_%d", minted)}
+		if lifetime > 0 {
+			response["expires_at"] = clock.Now().Add(lifetime).Format(time.RFC3339)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, &minted
+}
+
+func TestInstallationTokenSource(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	client, minted := newInstallationTokenServer(t, clock, time.Hour)
+
+	ts := NewInstallationTokenSource(client, 42, nil)
+	ts.Clock = clock
+
+	token, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "ghs_1" {
+		t.Errorf("token = %q, want ghs_1", token.AccessToken)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		token   string
+	}{
+		{advance: 30 * time.Minute, token: "ghs_1"},
+		{advance: 24*time.Minute + 59*time.Second, token: "ghs_1"},
+		{advance: time.Second, token: "ghs_2"},
+		{advance: 2 * time.Hour, token: "ghs_3"},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != step.token {
+			t.Fatalf("at %s: token = %q, want %q", clock.Now().Format(time.TimeOnly), token.AccessToken, step.token)
+		}
+	}
+	if *minted != 3 {
+		t.Errorf("minted %d tokens, want 3", *minted)
+	}
+}
+
+func TestInstallationTokenSourceWithoutExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	client, _ := newInstallationTokenServer(t, clock, 0)
+
+	ts := NewInstallationTokenSource(client, 42, nil)
+	ts.Clock = clock
+
+	token, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(installationFallback); !token.Expiry.Equal(want) {
+		t.Errorf("expiry = %s, want %s", token.Expiry, want)
+	}
+
+	clock.Advance(installationFallback - installationMargin)
+	token, err = ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "ghs_2" {
+		t.Errorf("token = %q, want ghs_2", token.AccessToken)
+	}
+}
+
+func decodeClaims(t *testing.T, tokenString string) map[string]interface{} {
+	t.Helper()
+
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed JWT %q", tokenString)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries      = 5
+	defaultMaxWait         = time.Hour
+	defaultBackoffBase     = time.Second
+	defaultBackoffMax      = 30 * time.Second
+	secondaryLimitFallback = time.Minute
+)
+
+// RateLimitTransport retries GitHub API requests that were rejected by the
+// primary or secondary rate limit, and transient 5xx responses to idempotent
+// requests.
+type RateLimitTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	MaxWait    time.Duration
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewRateLimitTransport(base http.RoundTripper) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &RateLimitTransport{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		MaxWait:    defaultMaxWait,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			var err error
+			r, err = rewindRequest(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.Base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, retry := t.retryDelay(req, resp, attempt)
+		if !retry || attempt >= t.MaxRetries {
+			return resp, nil
+		}
+		if t.MaxWait > 0 && wait > t.MaxWait {
+			log.Printf("GitHub API %s %s: wait of %s exceeds limit, giving up", req.Method, req.URL.Path, wait)
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		log.Printf("GitHub API %s %s returned %d, retrying in %s (attempt %d/%d)", req.Method, req.URL.Path, resp.StatusCode, wait.Round(time.Millisecond), attempt+1, t.MaxRetries)
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *RateLimitTransport) retryDelay(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			return t.parseRetryAfter(retryAfter), true
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return secondaryLimitFallback, true
+			}
+			wait := time.Unix(reset, 0).Sub(t.now()) + time.Second
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, true
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return secondaryLimitFallback, true
+		}
+		return 0, false
+	case resp.StatusCode == http.StatusInternalServerError,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		// A POST such as minting an installation token may have been applied
+		// before the error, so only idempotent requests are repeated.
+		if !isIdempotent(req.Method) {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+func (t *RateLimitTransport) parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(t.now()); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return secondaryLimitFallback
+}
+
+func backoff(attempt int) time.Duration {
+	wait := defaultBackoffBase << attempt
+	if wait > defaultBackoffMax || wait <= 0 {
+		wait = defaultBackoffMax
+	}
+	// Equal jitter: a random wait between half and the whole backoff window.
+	return wait/2 + rand.N(wait/2+1)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry %s %s: request body is not replayable", req.Method, req.URL.Path)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newResponse(status int, header map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	for name, value := range header {
+		resp.Header.Set(name, value)
+	}
+	return resp
+}
+
+// newTestTransport replays responses in order and records every sleep.
+func newTestTransport(now time.Time, responses ...*http.Response) (*RateLimitTransport, *[]time.Duration, *int) {
+	var sleeps []time.Duration
+	calls := 0
+
+	transport := NewRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := responses[min(calls, len(responses)-1)]
+		calls++
+		return resp, nil
+	}))
+	transport.now = func() time.Time { return now }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return transport, &sleeps, &calls
+}
+
+func TestRateLimitTransportRetryDelay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		header map[string]string
+		wait   time.Duration
+		retry  bool
+	}{
+		{
+			name:   "retry-after seconds",
+			status: http.StatusForbidden,
+			header: map[string]string{"Retry-After": "30"},
+			wait:   30 * time.Second,
+			retry:  true,
+		},
+		{
+			name:   "retry-after http date",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": now.Add(2 * time.Minute).Format(http.TimeFormat)},
+			wait:   2 * time.Minute,
+			retry:  true,
+		},
+		{
+			name:   "retry-after date in the past",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)},
+			wait:   0,
+			retry:  true,
+		},
+		{
+			name:   "retry-after invalid",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "soon"},
+			wait:   secondaryLimitFallback,
+			retry:  true,
+		},
+		{
+			name:   "primary rate limit reset",
+			status: http.StatusForbidden,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10),
+			},
+			wait:  10*time.Minute + time.Second,
+			retry: true,
+		},
+		{
+			name:   "primary rate limit reset in the past",
+			status: http.StatusForbidden,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10),
+			},
+			wait:  0,
+			retry: true,
+		},
+		{
+			name:   "primary rate limit without reset",
+			status: http.StatusForbidden,
+			header: map[string]string{"X-RateLimit-Remaining": "0"},
+			wait:   secondaryLimitFallback,
+			retry:  true,
+		},
+		{
+			name:   "secondary rate limit without headers",
+			status: http.StatusTooManyRequests,
+			wait:   secondaryLimitFallback,
+			retry:  true,
+		},
+		{
+			name:   "forbidden without rate limit headers",
+			status: http.StatusForbidden,
+			retry:  false,
+		},
+		{
+			name:   "rate limited post",
+			method: http.MethodPost,
+			status: http.StatusForbidden,
+			header: map[string]string{"Retry-After": "5"},
+			wait:   5 * time.Second,
+			retry:  true,
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+			retry:  false,
+		},
+		{
+			name:   "server error on post",
+			method: http.MethodPost,
+			status: http.StatusBadGateway,
+			retry:  false,
+		},
+		{
+			name:   "server error on patch",
+			method: http.MethodPatch,
+			status: http.StatusServiceUnavailable,
+			retry:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, _, _ := newTestTransport(now)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, _ := http.NewRequest(method, "https://api.github.com/app", nil)
+
+			wait, retry := transport.retryDelay(req, newResponse(tt.status, tt.header), 0)
+			if retry != tt.retry {
+				t.Fatalf("retry = %t, want %t", retry, tt.retry)
+			}
+			if retry && wait != tt.wait {
+				t.Errorf("wait = %s, want %s", wait, tt.wait)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportServerErrorBackoff(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			transport, _, _ := newTestTransport(time.Now())
+			req, _ := http.NewRequest(method, "https://api.github.com/app", nil)
+
+			for attempt := 0; attempt < 8; attempt++ {
+				window := min(defaultBackoffBase<<attempt, defaultBackoffMax)
+
+				wait, retry := transport.retryDelay(req, newResponse(http.StatusInternalServerError, nil), attempt)
+				if !retry {
+					t.Fatalf("attempt %d: expected retry", attempt)
+				}
+				if wait < window/2 || wait > window {
+					t.Errorf("attempt %d: wait %s outside [%s, %s]", attempt, wait, window/2, window)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportRoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limited := map[string]string{"Retry-After": "3"}
+
+	t.Run("retries until success", func(t *testing.T) {
+		transport, sleeps, calls := newTestTransport(now,
+			newResponse(http.StatusTooManyRequests, limited),
+			newResponse(http.StatusTooManyRequests, limited),
+			newResponse(http.StatusOK, nil),
+		)
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/app", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if *calls != 3 {
+			t.Errorf("calls = %d, want 3", *calls)
+		}
+		if len(*sleeps) != 2 || (*sleeps)[0] != 3*time.Second {
+			t.Errorf("sleeps = %v, want two waits of 3s", *sleeps)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		transport, sleeps, calls := newTestTransport(now, newResponse(http.StatusTooManyRequests, limited))
+		transport.MaxRetries = 2
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/app", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+		}
+		if *calls != 3 || len(*sleeps) != 2 {
+			t.Errorf("calls = %d, sleeps = %d, want 3 and 2", *calls, len(*sleeps))
+		}
+	})
+
+	t.Run("gives up when the wait exceeds the limit", func(t *testing.T) {
+		transport, sleeps, calls := newTestTransport(now, newResponse(http.StatusForbidden, map[string]string{"Retry-After": "7200"}))
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/app", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if *calls != 1 || len(*sleeps) != 0 {
+			t.Errorf("calls = %d, sleeps = %d, want 1 and 0", *calls, len(*sleeps))
+		}
+	})
+
+	t.Run("does not repeat a failed post", func(t *testing.T) {
+		transport, _, calls := newTestTransport(now,
+			newResponse(http.StatusBadGateway, nil),
+			newResponse(http.StatusCreated, nil),
+		)
+
+		req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/app/installations/1/access_tokens", strings.NewReader("{}"))
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusBadGateway || *calls != 1 {
+			t.Errorf("status = %d, calls = %d, want %d and 1", resp.StatusCode, *calls, http.StatusBadGateway)
+		}
+	})
+
+	t.Run("replays the request body", func(t *testing.T) {
+		var bodies []string
+		transport := NewRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				return newResponse(http.StatusTooManyRequests, limited), nil
+			}
+			return newResponse(http.StatusCreated, nil), nil
+		}))
+		transport.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+		req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/app/installations/1/access_tokens", strings.NewReader(`{"repositories":["a"]}`))
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if len(bodies) != 2 || bodies[0] != bodies[1] {
+			t.Errorf("bodies = %q, want the same body twice", bodies)
+		}
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		transport, _, _ := newTestTransport(now, newResponse(http.StatusTooManyRequests, limited))
+		transport.sleep = sleepContext
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/app", nil)
+		if _, err := transport.RoundTrip(req); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
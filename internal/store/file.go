@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+type FileSource struct {
+	Path string
+}
+
+func (f *FileSource) Load(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template file, %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/coding-ia/renovate-controller/internal/service"
+	"github.com/google/go-github/v63/github"
+	"strings"
+)
+
+// GitHubSource reads a file from a repository the app is installed on,
+// addressed as owner/repo@ref:path. The ref is optional.
+type GitHubSource struct {
+	Owner string
+	Repo  string
+	Ref   string
+	Path  string
+
+	client *github.Client
+}
+
+func NewGitHubSource(location string, client *github.Client) (*GitHubSource, error) {
+	repository, path, found := strings.Cut(location, ":")
+	if !found || path == "" {
+		return nil, fmt.Errorf("invalid GitHub template source %q, expected github://owner/repo@ref:path", location)
+	}
+
+	repository, ref, _ := strings.Cut(repository, "@")
+	owner, repo, found := strings.Cut(repository, "/")
+	if !found || owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid GitHub template source %q, expected github://owner/repo@ref:path", location)
+	}
+
+	if client == nil {
+		return nil, fmt.Errorf("GitHub template source %q requires a GitHub client", location)
+	}
+
+	return &GitHubSource{
+		Owner:  owner,
+		Repo:   repo,
+		Ref:    ref,
+		Path:   strings.TrimPrefix(path, "/"),
+		client: client,
+	}, nil
+}
+
+func (g *GitHubSource) Load(ctx context.Context) (string, error) {
+	installation, _, err := g.client.Apps.FindRepositoryInstallation(ctx, g.Owner, g.Repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to find installation for %s/%s, %v", g.Owner, g.Repo, err)
+	}
+
+	tokenOptions := &github.InstallationTokenOptions{
+		Repositories: []string{g.Repo},
+		Permissions:  &github.InstallationPermissions{Contents: github.String("read")},
+	}
+	ts := service.NewInstallationTokenSource(g.client, installation.GetID(), tokenOptions)
+	installationClient := service.DeriveClient(g.client, ts)
+
+	var opts *github.RepositoryContentGetOptions
+	if g.Ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: g.Ref}
+	}
+
+	file, _, _, err := installationClient.Repositories.GetContents(ctx, g.Owner, g.Repo, g.Path, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s from %s/%s, %v", g.Path, g.Owner, g.Repo, err)
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s in %s/%s is not a file", g.Path, g.Owner, g.Repo)
+	}
+
+	return file.GetContent()
+}
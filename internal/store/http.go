@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// HTTPSource downloads a template over HTTP(S) and keeps a copy in CacheDir
+// so that unchanged templates are revalidated with If-None-Match.
+type HTTPSource struct {
+	URL      string
+	CacheDir string
+	Client   *http.Client
+}
+
+func (h *HTTPSource) Load(ctx context.Context) (string, error) {
+	cacheDir := h.CacheDir
+	if cacheDir == "" {
+		cacheDir = defaultCacheDir()
+	}
+
+	sum := sha256.Sum256([]byte(h.URL))
+	cacheKey := filepath.Join(cacheDir, hex.EncodeToString(sum[:]))
+	bodyPath := cacheKey + ".body"
+	etagPath := cacheKey + ".etag"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	cached, cacheErr := os.ReadFile(bodyPath)
+	etag, etagErr := os.ReadFile(etagPath)
+	if cacheErr == nil && etagErr == nil && len(etag) > 0 {
+		req.Header.Set("If-None-Match", string(etag))
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download template, %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cacheErr != nil {
+			return "", fmt.Errorf("template %s not modified but no cached copy exists", h.URL)
+		}
+		return string(cached), nil
+	case http.StatusOK:
+	default:
+		return "", fmt.Errorf("failed to download template %s, status %s", h.URL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template, %v", err)
+	}
+
+	if newEtag := resp.Header.Get("ETag"); newEtag != "" {
+		if err := writeCache(cacheDir, bodyPath, etagPath, body, newEtag); err != nil {
+			log.Printf("Unable to cache template %s: %v", h.URL, err)
+		}
+	}
+
+	return string(body), nil
+}
+
+func writeCache(cacheDir string, bodyPath string, etagPath string, body []byte, etag string) error {
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(bodyPath, body, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(etagPath, []byte(etag), 0600)
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "renovate-controller", "templates")
+}
@@ -0,0 +1,89 @@
+//go:build localstack
+
+package store
+
+// See service/localstack_test.go for how to run the LocalStack suite.
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/coding-ia/renovate-controller/internal/awsclient"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLocalStackTemplateSources(t *testing.T) {
+	if os.Getenv("AWS_ENDPOINT_URL") == "" {
+		t.Skip("AWS_ENDPOINT_URL is not set")
+	}
+
+	ctx := context.Background()
+	clients, err := awsclient.Load(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LocalStack serves S3 on path-style URLs only.
+	s3Client := s3.NewFromConfig(clients.Config, func(o *s3.Options) {
+		o.UsePathStyle = true
+	})
+
+	bucket := "renovate-localstack"
+	if _, err := s3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String("config.tmpl"),
+		Body:   strings.NewReader("s3-template"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String("config.tmpl")})
+		s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(bucket)})
+	})
+
+	_, err = clients.SSM.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String("/renovate/localstack"),
+		Value:     aws.String("ssm-template"),
+		Type:      ssmtypes.ParameterTypeSecureString,
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clients.SSM.DeleteParameter(ctx, &ssm.DeleteParameterInput{Name: aws.String("/renovate/localstack")})
+	})
+
+	sources := Clients{S3: s3Client, SSM: clients.SSM}
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "s3://" + bucket + "/config.tmpl", want: "s3-template"},
+		{uri: "ssm:///renovate/localstack", want: "ssm-template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			source, err := NewTemplateSource(tt.uri, sources)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := source.Load(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Load = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v63/github"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type TemplateSource interface {
+	Load(ctx context.Context) (string, error)
+}
+
+// Clients are used by the template sources that need one. The GitHub client
+// is only used by github:// sources and must be authenticated as the app.
+type Clients struct {
+	GitHub *github.Client
+	S3     S3API
+	SSM    SSMAPI
+	HTTP   *http.Client
+}
+
+// NewTemplateSource resolves a template URI to its source. Supported schemes
+// are s3://, file://, ssm://, github:// and http(s)://.
+func NewTemplateSource(uri string, clients Clients) (TemplateSource, error) {
+	scheme, rest, found := strings.Cut(uri, "://")
+	if !found {
+		return nil, fmt.Errorf("template source %q has no scheme", uri)
+	}
+
+	switch strings.ToLower(scheme) {
+	case "s3":
+		bucket, key, _ := strings.Cut(rest, "/")
+		if bucket == "" || key == "" {
+			return nil, fmt.Errorf("invalid S3 template source %q, expected s3://<bucket>/<key>", uri)
+		}
+		return &S3Source{Bucket: bucket, Key: key, Client: clients.S3}, nil
+	case "file":
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+		path := u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			// file://relative/path
+			path = u.Host + u.Path
+		}
+		return &FileSource{Path: path}, nil
+	case "ssm":
+		name := rest
+		if !strings.HasPrefix(name, "/") && strings.Contains(name, "/") {
+			name = "/" + name
+		}
+		return &SSMSource{Name: name, Client: clients.SSM}, nil
+	case "github":
+		return NewGitHubSource(rest, clients.GitHub)
+	case "http", "https":
+		return &HTTPSource{URL: uri, Client: clients.HTTP}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported template source scheme %q", scheme)
+}
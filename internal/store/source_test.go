@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"io"
+	"strings"
+	"testing"
+)
+
+type fakeS3 struct {
+	objects map[string]string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := f.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+type fakeSSM struct {
+	parameters map[string]string
+	decrypted  bool
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.decrypted = aws.ToBool(params.WithDecryption)
+	value, ok := f.parameters[aws.ToString(params.Name)]
+	if !ok {
+		return nil, errors.New("ParameterNotFound")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(value)}}, nil
+}
+
+func TestAWSTemplateSources(t *testing.T) {
+	clients := Clients{
+		S3:  &fakeS3{objects: map[string]string{"config-bucket/renovate/config.tmpl": "{{ .Repository }}"}},
+		SSM: &fakeSSM{parameters: map[string]string{"/renovate/template": "ssm-template", "template": "short-name"}},
+	}
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "s3://config-bucket/renovate/config.tmpl", want: "{{ .Repository }}"},
+		{uri: "ssm:///renovate/template", want: "ssm-template"},
+		{uri: "ssm://renovate/template", want: "ssm-template"},
+		{uri: "ssm://template", want: "short-name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			source, err := NewTemplateSource(tt.uri, clients)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := source.Load(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Load = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !clients.SSM.(*fakeSSM).decrypted {
+		t.Error("SSM parameters must be read with decryption")
+	}
+}
+
+func TestAWSTemplateSourceErrors(t *testing.T) {
+	clients := Clients{
+		S3:  &fakeS3{},
+		SSM: &fakeSSM{},
+	}
+
+	tests := []struct {
+		uri     string
+		clients Clients
+		err     string
+	}{
+		{uri: "s3://config-bucket", clients: clients, err: "expected s3://<bucket>/<key>"},
+		{uri: "s3://config-bucket/missing", clients: clients, err: "NoSuchKey"},
+		{uri: "s3://config-bucket/config.tmpl", err: "no S3 client configured"},
+		{uri: "ssm:///renovate/missing", clients: clients, err: "ParameterNotFound"},
+		{uri: "ssm:///renovate/template", err: "no SSM client configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			source, err := NewTemplateSource(tt.uri, tt.clients)
+			if err == nil {
+				_, err = source.Load(context.Background())
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
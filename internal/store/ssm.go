@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type SSMAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+type SSMSource struct {
+	Name   string
+	Client SSMAPI
+}
+
+func (s *SSMSource) Load(ctx context.Context) (string, error) {
+	return GetSSMParameter(ctx, s.Client, s.Name)
+}
+
+func GetSSMParameter(ctx context.Context, ssmClient SSMAPI, name string) (string, error) {
+	if ssmClient == nil {
+		return "", fmt.Errorf("no SSM client configured")
+	}
+
+	output, err := ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get SSM parameter, %v", err)
+	}
+
+	return aws.ToString(output.Parameter.Value), nil
+}
@@ -5,20 +5,29 @@ import (
 	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"io"
 )
 
-func GetS3Object(bucketName string, key string) (string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return "", fmt.Errorf("unable to load SDK config, %v", err)
-	}
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
 
-	s3Client := s3.NewFromConfig(cfg)
+type S3Source struct {
+	Bucket string
+	Key    string
+	Client S3API
+}
+
+func (s *S3Source) Load(ctx context.Context) (string, error) {
+	if s.Client == nil {
+		return "", fmt.Errorf("no S3 client configured")
+	}
+	return getS3Object(ctx, s.Client, s.Bucket, s.Key)
+}
 
-	getObjectOutput, err := s3Client.GetObject(context.TODO(), &s3.GetObjectInput{
+func getS3Object(ctx context.Context, s3Client S3API, bucketName string, key string) (string, error) {
+	getObjectOutput, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    aws.String(key),
 	})
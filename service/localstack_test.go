@@ -0,0 +1,156 @@
+//go:build localstack
+
+package service
+
+// The LocalStack suite runs RunTask against real EC2 and ECS APIs:
+//
+//	docker run -d -p 4566:4566 localstack/localstack
+//	AWS_ENDPOINT_URL=http://localhost:4566 AWS_REGION=us-east-1 \
+//	AWS_ACCESS_KEY_ID=test AWS_SECRET_ACCESS_KEY=test \
+//	go test -tags localstack ./service ./internal/store
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/coding-ia/renovate-controller/internal/awsclient"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func localStackClients(t *testing.T) *awsclient.Clients {
+	t.Helper()
+
+	if os.Getenv("AWS_ENDPOINT_URL") == "" {
+		t.Skip("AWS_ENDPOINT_URL is not set")
+	}
+
+	clients, err := awsclient.Load(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clients
+}
+
+func tagSpecification(resourceType ec2types.ResourceType, key string) []ec2types.TagSpecification {
+	return []ec2types.TagSpecification{
+		{
+			ResourceType: resourceType,
+			Tags:         []ec2types.Tag{{Key: aws.String(key), Value: aws.String("true")}},
+		},
+	}
+}
+
+// createTaggedNetwork creates a VPC with a subnet and security group tagged
+// the way NetworkCache looks them up.
+func createTaggedNetwork(t *testing.T, ec2Client *ec2.Client) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	vpc, err := ec2Client.CreateVpc(ctx, &ec2.CreateVpcInput{CidrBlock: aws.String("10.42.0.0/16")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpcID := vpc.Vpc.VpcId
+
+	subnet, err := ec2Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		VpcId:             vpcID,
+		CidrBlock:         aws.String("10.42.1.0/24"),
+		TagSpecifications: tagSpecification(ec2types.ResourceTypeSubnet, "allow-renovate"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, err := ec2Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+		VpcId:             vpcID,
+		GroupName:         aws.String("renovate-" + t.Name()),
+		Description:       aws.String("renovate-controller LocalStack suite"),
+		TagSpecifications: tagSpecification(ec2types.ResourceTypeSecurityGroup, "renovate"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: group.GroupId})
+		ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.Subnet.SubnetId})
+		ec2Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: vpcID})
+	})
+
+	return aws.ToString(subnet.Subnet.SubnetId), aws.ToString(group.GroupId)
+}
+
+func TestLocalStackNetworkCache(t *testing.T) {
+	clients := localStackClients(t)
+	subnetID, groupID := createTaggedNetwork(t, clients.EC2)
+
+	cache := NewNetworkCache(clients.EC2)
+	subnets, err := cache.Subnets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	securityGroups, err := cache.SecurityGroups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(subnets, []string{subnetID}) {
+		t.Errorf("subnets = %v, want [%s]", subnets, subnetID)
+	}
+	if !reflect.DeepEqual(securityGroups, []string{groupID}) {
+		t.Errorf("security groups = %v, want [%s]", securityGroups, groupID)
+	}
+}
+
+func TestLocalStackRunTask(t *testing.T) {
+	clients := localStackClients(t)
+	ctx := context.Background()
+	createTaggedNetwork(t, clients.EC2)
+
+	cluster, err := clients.ECS.CreateCluster(ctx, &ecs.CreateClusterInput{ClusterName: aws.String("renovate-localstack")})
+	if err != nil {
+		t.Skipf("ECS is not available in this LocalStack edition: %v", err)
+	}
+	t.Cleanup(func() {
+		clients.ECS.DeleteCluster(ctx, &ecs.DeleteClusterInput{Cluster: cluster.Cluster.ClusterArn})
+	})
+
+	taskDefinition, err := clients.ECS.RegisterTaskDefinition(ctx, &ecs.RegisterTaskDefinitionInput{
+		Family:                  aws.String("renovate-localstack"),
+		NetworkMode:             types.NetworkModeAwsvpc,
+		RequiresCompatibilities: []types.Compatibility{types.CompatibilityFargate},
+		Cpu:                     aws.String("256"),
+		Memory:                  aws.String("512"),
+		ContainerDefinitions: []types.ContainerDefinition{
+			{Name: aws.String("init"), Image: aws.String("renovate-controller:latest"), Essential: aws.Bool(false)},
+			{Name: aws.String("renovate"), Image: aws.String("renovate/renovate:latest"), Essential: aws.Bool(true)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := ECSConfig{
+		Cluster:              aws.ToString(cluster.Cluster.ClusterArn),
+		Task:                 aws.ToString(taskDefinition.TaskDefinition.TaskDefinitionArn),
+		Container:            "renovate",
+		ContainerEnvironment: map[string]string{"NODE_EXTRA_CA_CERTS": "/etc/ssl/ca.pem"},
+	}
+	svc := NewRenovateTaskService(config, clients.ECS, NewNetworkCache(clients.EC2))
+
+	output, err := svc.RunTask(RunTaskConfig{InstallationID: "42", Repository: "octo/repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Failures) > 0 {
+		t.Fatalf("RunTask failures = %v", output.Failures)
+	}
+	if len(output.Tasks) != 1 {
+		t.Fatalf("started %d tasks, want 1", len(output.Tasks))
+	}
+}
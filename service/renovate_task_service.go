@@ -4,12 +4,13 @@ import (
 	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"log"
+	"sort"
+	"sync"
 )
 
 type ECSVPCConfig struct {
@@ -18,24 +19,41 @@ type ECSVPCConfig struct {
 	SecurityGroups []string
 }
 
+// ECSConfig describes the launched task. InitEnvironment is added to the
+// "init" container, ContainerEnvironment to the Renovate container.
 type ECSConfig struct {
-	Cluster      string
-	Task         string
-	Container    string
-	AWSVPCConfig ECSVPCConfig
+	Cluster              string
+	Task                 string
+	Container            string
+	AWSVPCConfig         ECSVPCConfig
+	InitEnvironment      map[string]string
+	ContainerEnvironment map[string]string
+}
+
+type ECSAPI interface {
+	RunTask(ctx context.Context, params *ecs.RunTaskInput, optFns ...func(*ecs.Options)) (*ecs.RunTaskOutput, error)
+}
+
+type EC2API interface {
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
 }
 
 type TaskService struct {
-	Config ECSConfig
+	Config  ECSConfig
+	ECS     ECSAPI
+	Network *NetworkCache
 }
 
 type RenovateTaskService interface {
-	RunTask(installationToken string, repository string, endpoint string) (*ecs.RunTaskOutput, error)
+	RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, error)
 }
 
-func NewRenovateTaskService(config ECSConfig) *TaskService {
+func NewRenovateTaskService(config ECSConfig, ecsClient ECSAPI, network *NetworkCache) *TaskService {
 	return &TaskService{
-		Config: config,
+		Config:  config,
+		ECS:     ecsClient,
+		Network: network,
 	}
 }
 
@@ -46,18 +64,12 @@ type RunTaskConfig struct {
 }
 
 func (t *TaskService) RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return nil, err
-	}
-
-	svc := ecs.NewFromConfig(cfg)
-
 	var subnets []string
 	var securityGroups []string
+	var err error
 
 	if len(t.Config.AWSVPCConfig.Subnets) == 0 {
-		subnets, err = filterSubnets()
+		subnets, err = t.Network.Subnets(context.TODO())
 		if err != nil {
 			return nil, err
 		}
@@ -70,11 +82,13 @@ func (t *TaskService) RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, erro
 	}
 
 	if len(t.Config.AWSVPCConfig.SecurityGroups) == 0 {
-		securityGroups, err = filterSecurityGroups()
+		securityGroups, err = t.Network.SecurityGroups(context.TODO())
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		securityGroups = t.Config.AWSVPCConfig.SecurityGroups
 	}
-
 	assignPublicIP := types.AssignPublicIpDisabled
 	if t.Config.AWSVPCConfig.AssignPublicIP {
 		assignPublicIP = types.AssignPublicIpEnabled
@@ -99,7 +113,7 @@ func (t *TaskService) RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, erro
 			ContainerOverrides: []types.ContainerOverride{
 				{
 					Name: aws.String("init"),
-					Environment: []types.KeyValuePair{
+					Environment: append([]types.KeyValuePair{
 						{
 							Name:  aws.String("GITHUB_INSTALLATION_ID"),
 							Value: aws.String(runConfig.InstallationID),
@@ -108,13 +122,20 @@ func (t *TaskService) RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, erro
 							Name:  aws.String("GITHUB_TARGET_REPOSITORY"),
 							Value: aws.String(runConfig.Repository),
 						},
-					},
+					}, keyValuePairs(t.Config.InitEnvironment)...),
 				},
 			},
 		},
 	}
 
-	runTaskOutput, err := svc.RunTask(context.TODO(), runTaskInput)
+	if t.Config.Container != "" && len(t.Config.ContainerEnvironment) > 0 {
+		runTaskInput.Overrides.ContainerOverrides = append(runTaskInput.Overrides.ContainerOverrides, types.ContainerOverride{
+			Name:        aws.String(t.Config.Container),
+			Environment: keyValuePairs(t.Config.ContainerEnvironment),
+		})
+	}
+
+	runTaskOutput, err := t.ECS.RunTask(context.TODO(), runTaskInput)
 	if err != nil {
 		return nil, err
 	}
@@ -122,14 +143,76 @@ func (t *TaskService) RunTask(runConfig RunTaskConfig) (*ecs.RunTaskOutput, erro
 	return runTaskOutput, nil
 }
 
-func filterSubnets() ([]string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+func keyValuePairs(environment map[string]string) []types.KeyValuePair {
+	names := make([]string, 0, len(environment))
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []types.KeyValuePair
+	for _, name := range names {
+		pairs = append(pairs, types.KeyValuePair{
+			Name:  aws.String(name),
+			Value: aws.String(environment[name]),
+		})
+	}
+	return pairs
+}
+
+// NetworkCache resolves the tagged subnets and security groups once per run
+// instead of once per launched task.
+type NetworkCache struct {
+	EC2 EC2API
+
+	mu             sync.Mutex
+	subnets        []string
+	securityGroups []string
+	subnetsOK      bool
+	groupsOK       bool
+}
+
+func NewNetworkCache(ec2Client EC2API) *NetworkCache {
+	return &NetworkCache{
+		EC2: ec2Client,
+	}
+}
+
+func (c *NetworkCache) Subnets(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.subnetsOK {
+		return c.subnets, nil
+	}
+
+	subnets, err := filterSubnets(ctx, c.EC2)
+	if err != nil {
+		return nil, err
+	}
+
+	c.subnets, c.subnetsOK = subnets, true
+	return subnets, nil
+}
+
+func (c *NetworkCache) SecurityGroups(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.groupsOK {
+		return c.securityGroups, nil
+	}
+
+	securityGroups, err := filterSecurityGroups(ctx, c.EC2)
 	if err != nil {
 		return nil, err
 	}
 
-	ec2Client := ec2.NewFromConfig(cfg)
+	c.securityGroups, c.groupsOK = securityGroups, true
+	return securityGroups, nil
+}
 
+func filterSubnets(ctx context.Context, ec2Client EC2API) ([]string, error) {
 	describeSubnetsInput := &ec2.DescribeSubnetsInput{
 		Filters: []ec2types.Filter{
 			{
@@ -139,7 +222,7 @@ func filterSubnets() ([]string, error) {
 		},
 	}
 
-	result, err := ec2Client.DescribeSubnets(context.TODO(), describeSubnetsInput)
+	result, err := ec2Client.DescribeSubnets(ctx, describeSubnetsInput)
 	if err != nil {
 		return nil, err
 	}
@@ -152,14 +235,7 @@ func filterSubnets() ([]string, error) {
 	return subnetIDs, nil
 }
 
-func filterSecurityGroups() ([]string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return nil, err
-	}
-
-	ec2Client := ec2.NewFromConfig(cfg)
-
+func filterSecurityGroups(ctx context.Context, ec2Client EC2API) ([]string, error) {
 	filters := []ec2types.Filter{
 		{
 			Name:   aws.String("tag:renovate"),
@@ -167,7 +243,7 @@ func filterSecurityGroups() ([]string, error) {
 		},
 	}
 
-	result, err := ec2Client.DescribeSecurityGroups(context.TODO(), &ec2.DescribeSecurityGroupsInput{
+	result, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
 		Filters: filters,
 	})
 	if err != nil {
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeECS struct {
+	inputs []*ecs.RunTaskInput
+	err    error
+}
+
+func (f *fakeECS) RunTask(ctx context.Context, params *ecs.RunTaskInput, optFns ...func(*ecs.Options)) (*ecs.RunTaskOutput, error) {
+	f.inputs = append(f.inputs, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ecs.RunTaskOutput{}, nil
+}
+
+type fakeEC2 struct {
+	subnets        []string
+	securityGroups []string
+	subnetErr      error
+	groupErr       error
+
+	subnetCalls int
+	groupCalls  int
+	filters     []ec2types.Filter
+}
+
+func (f *fakeEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	f.subnetCalls++
+	f.filters = append(f.filters, params.Filters...)
+	if f.subnetErr != nil {
+		return nil, f.subnetErr
+	}
+
+	output := &ec2.DescribeSubnetsOutput{}
+	for _, id := range f.subnets {
+		output.Subnets = append(output.Subnets, ec2types.Subnet{SubnetId: aws.String(id)})
+	}
+	return output, nil
+}
+
+func (f *fakeEC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	f.groupCalls++
+	f.filters = append(f.filters, params.Filters...)
+	if f.groupErr != nil {
+		return nil, f.groupErr
+	}
+
+	output := &ec2.DescribeSecurityGroupsOutput{}
+	for _, id := range f.securityGroups {
+		output.SecurityGroups = append(output.SecurityGroups, ec2types.SecurityGroup{GroupId: aws.String(id)})
+	}
+	return output, nil
+}
+
+func TestRunTaskNetwork(t *testing.T) {
+	tests := []struct {
+		name           string
+		vpc            ECSVPCConfig
+		ec2            *fakeEC2
+		subnets        []string
+		securityGroups []string
+		assignPublicIP types.AssignPublicIp
+		subnetCalls    int
+		groupCalls     int
+	}{
+		{
+			name:           "configured",
+			vpc:            ECSVPCConfig{Subnets: []string{"subnet-a"}, SecurityGroups: []string{"sg-a"}},
+			ec2:            &fakeEC2{subnets: []string{"subnet-tagged"}, securityGroups: []string{"sg-tagged"}},
+			subnets:        []string{"subnet-a"},
+			securityGroups: []string{"sg-a"},
+			assignPublicIP: types.AssignPublicIpDisabled,
+		},
+		{
+			name:           "tagged fallback",
+			ec2:            &fakeEC2{subnets: []string{"subnet-1", "subnet-2"}, securityGroups: []string{"sg-1"}},
+			subnets:        []string{"subnet-1", "subnet-2"},
+			securityGroups: []string{"sg-1"},
+			assignPublicIP: types.AssignPublicIpDisabled,
+			subnetCalls:    1,
+			groupCalls:     1,
+		},
+		{
+			name:           "configured subnets with tagged security groups",
+			vpc:            ECSVPCConfig{Subnets: []string{"subnet-a"}, AssignPublicIP: true},
+			ec2:            &fakeEC2{securityGroups: []string{"sg-1"}},
+			subnets:        []string{"subnet-a"},
+			securityGroups: []string{"sg-1"},
+			assignPublicIP: types.AssignPublicIpEnabled,
+			groupCalls:     1,
+		},
+		{
+			name:           "no security groups",
+			vpc:            ECSVPCConfig{Subnets: []string{"subnet-a"}},
+			ec2:            &fakeEC2{},
+			subnets:        []string{"subnet-a"},
+			assignPublicIP: types.AssignPublicIpDisabled,
+			groupCalls:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &fakeECS{}
+			config := ECSConfig{Cluster: "renovate", Task: "renovate:3", AWSVPCConfig: tt.vpc}
+			svc := NewRenovateTaskService(config, ecsClient, NewNetworkCache(tt.ec2))
+
+			// The second task reuses the cached lookups.
+			for i := 0; i < 2; i++ {
+				if _, err := svc.RunTask(RunTaskConfig{InstallationID: "1", Repository: "octo/repo"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			input := ecsClient.inputs[0]
+			if aws.ToString(input.Cluster) != "renovate" || aws.ToString(input.TaskDefinition) != "renovate:3" {
+				t.Errorf("cluster/task = %q/%q", aws.ToString(input.Cluster), aws.ToString(input.TaskDefinition))
+			}
+			if input.LaunchType != types.LaunchTypeFargate {
+				t.Errorf("launch type = %q, want FARGATE", input.LaunchType)
+			}
+
+			vpc := input.NetworkConfiguration.AwsvpcConfiguration
+			if !reflect.DeepEqual(vpc.Subnets, tt.subnets) {
+				t.Errorf("subnets = %v, want %v", vpc.Subnets, tt.subnets)
+			}
+			if !reflect.DeepEqual(vpc.SecurityGroups, tt.securityGroups) {
+				t.Errorf("security groups = %v, want %v", vpc.SecurityGroups, tt.securityGroups)
+			}
+			if vpc.AssignPublicIp != tt.assignPublicIP {
+				t.Errorf("assign public IP = %q, want %q", vpc.AssignPublicIp, tt.assignPublicIP)
+			}
+			if tt.ec2.subnetCalls != tt.subnetCalls || tt.ec2.groupCalls != tt.groupCalls {
+				t.Errorf("EC2 calls = %d subnets, %d groups, want %d and %d", tt.ec2.subnetCalls, tt.ec2.groupCalls, tt.subnetCalls, tt.groupCalls)
+			}
+		})
+	}
+}
+
+func TestNetworkCacheFilters(t *testing.T) {
+	ec2Client := &fakeEC2{subnets: []string{"subnet-1"}, securityGroups: []string{"sg-1"}}
+	cache := NewNetworkCache(ec2Client)
+
+	if _, err := cache.Subnets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.SecurityGroups(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, filter := range ec2Client.filters {
+		got = append(got, aws.ToString(filter.Name)+"="+strings.Join(filter.Values, ","))
+	}
+	want := []string{"tag:allow-renovate=true", "tag:renovate=true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filters = %v, want %v", got, want)
+	}
+}
+
+func TestRunTaskOverrides(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ECSConfig
+		want   map[string][]string
+	}{
+		{
+			name: "init only",
+			config: ECSConfig{
+				Container: "renovate",
+			},
+			want: map[string][]string{
+				"init": {"GITHUB_INSTALLATION_ID=42", "GITHUB_TARGET_REPOSITORY=octo/repo"},
+			},
+		},
+		{
+			name: "init environment is sorted after the task variables",
+			config: ECSConfig{
+				InitEnvironment: map[string]string{"SSL_CERT_FILE": "/etc/ssl/ca.pem", "HTTPS_PROXY": "http://proxy:3128"},
+			},
+			want: map[string][]string{
+				"init": {"GITHUB_INSTALLATION_ID=42", "GITHUB_TARGET_REPOSITORY=octo/repo", "HTTPS_PROXY=http://proxy:3128", "SSL_CERT_FILE=/etc/ssl/ca.pem"},
+			},
+		},
+		{
+			name: "container environment",
+			config: ECSConfig{
+				Container:            "renovate",
+				InitEnvironment:      map[string]string{"SSL_CERT_FILE": "/etc/ssl/ca.pem"},
+				ContainerEnvironment: map[string]string{"NODE_EXTRA_CA_CERTS": "/etc/ssl/ca.pem", "HTTPS_PROXY": "http://proxy:3128"},
+			},
+			want: map[string][]string{
+				"init":     {"GITHUB_INSTALLATION_ID=42", "GITHUB_TARGET_REPOSITORY=octo/repo", "SSL_CERT_FILE=/etc/ssl/ca.pem"},
+				"renovate": {"HTTPS_PROXY=http://proxy:3128", "NODE_EXTRA_CA_CERTS=/etc/ssl/ca.pem"},
+			},
+		},
+		{
+			name: "container environment without container name",
+			config: ECSConfig{
+				ContainerEnvironment: map[string]string{"NODE_EXTRA_CA_CERTS": "/etc/ssl/ca.pem"},
+			},
+			want: map[string][]string{
+				"init": {"GITHUB_INSTALLATION_ID=42", "GITHUB_TARGET_REPOSITORY=octo/repo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &fakeECS{}
+			tt.config.AWSVPCConfig = ECSVPCConfig{Subnets: []string{"subnet-a"}, SecurityGroups: []string{"sg-a"}}
+			svc := NewRenovateTaskService(tt.config, ecsClient, NewNetworkCache(&fakeEC2{}))
+
+			if _, err := svc.RunTask(RunTaskConfig{InstallationID: "42", Repository: "octo/repo"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string][]string{}
+			for _, override := range ecsClient.inputs[0].Overrides.ContainerOverrides {
+				var environment []string
+				for _, pair := range override.Environment {
+					environment = append(environment, aws.ToString(pair.Name)+"="+aws.ToString(pair.Value))
+				}
+				got[aws.ToString(override.Name)] = environment
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overrides = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTaskErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		vpc  ECSVPCConfig
+		ec2  *fakeEC2
+		ecs  *fakeECS
+		err  string
+	}{
+		{name: "no tagged subnets", ec2: &fakeEC2{}, ecs: &fakeECS{}, err: "no subnets found"},
+		{name: "describe subnets", ec2: &fakeEC2{subnetErr: errors.New("UnauthorizedOperation")}, ecs: &fakeECS{}, err: "UnauthorizedOperation"},
+		{name: "describe security groups", vpc: ECSVPCConfig{Subnets: []string{"subnet-a"}}, ec2: &fakeEC2{groupErr: errors.New("RequestLimitExceeded")}, ecs: &fakeECS{}, err: "RequestLimitExceeded"},
+		{name: "run task", vpc: ECSVPCConfig{Subnets: []string{"subnet-a"}, SecurityGroups: []string{"sg-a"}}, ec2: &fakeEC2{}, ecs: &fakeECS{err: errors.New("ClusterNotFoundException")}, err: "ClusterNotFoundException"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewRenovateTaskService(ECSConfig{AWSVPCConfig: tt.vpc}, tt.ecs, NewNetworkCache(tt.ec2))
+
+			_, err := svc.RunTask(RunTaskConfig{InstallationID: "42", Repository: "octo/repo"})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("RunTask error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}